@@ -0,0 +1,72 @@
+package shopify
+
+// access scopes are not versioned, the path is not prefixed with the api
+// version
+const accessScopesPath = "admin/oauth/access_scopes.json"
+
+// AccessScopeService is an interface for interfacing with the access scope
+// endpoint of the Shopify API.
+// See: https://shopify.dev/api/admin-rest/latest/resources/accessscope
+type AccessScopeService interface {
+	// Retrieves the scopes granted to the access token
+	List(options interface{}) ([]AccessScope, error)
+	// Retrieves the scopes of required, a comma separated list as in
+	// App.Scope, not granted to the access token
+	Missing(required string) ([]string, error)
+}
+
+// AccessScope is a scope granted to an access token.
+type AccessScope struct {
+	Handle string `json:"handle"`
+}
+
+// AccessScopeServiceOp handles communication with the access scope related
+// methods of the Shopify API.
+type AccessScopeServiceOp struct {
+	client *Client
+}
+
+// AccessScopesResource represents the result from the
+// oauth/access_scopes.json endpoint
+type AccessScopesResource struct {
+	AccessScopes []AccessScope `json:"access_scopes"`
+}
+
+// List the scopes granted to the access token of the client.
+func (s *AccessScopeServiceOp) List(options interface{}) ([]AccessScope, error) {
+	req, err := s.client.NewRequestWithContext(s.client.context(), "GET", accessScopesPath, nil, options)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := new(AccessScopesResource)
+	err = s.client.Do(req, resource)
+	return resource.AccessScopes, err
+}
+
+// Missing returns the scopes of required not granted to the access token of
+// the client, see MissingScopes.
+func (s *AccessScopeServiceOp) Missing(required string) ([]string, error) {
+	scopes, err := s.List(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	granted := make([]string, len(scopes))
+	for i, scope := range scopes {
+		granted[i] = scope.Handle
+	}
+	return MissingScopes(required, granted), nil
+}
+
+// ReauthorizeUrl returns the url the merchant must approve for the shop of
+// client to grant the scopes of the app its token lacks, e.g. after scopes
+// were added to App.Scope. It returns an empty url when every scope is
+// granted.
+func (app App) ReauthorizeUrl(client *Client, state string, opts ...AuthorizeOption) (string, error) {
+	missing, err := client.AccessScope.Missing(app.Scope)
+	if err != nil || len(missing) == 0 {
+		return "", err
+	}
+	return app.AuthorizeUrl(client.baseURL.Host, state, opts...), nil
+}
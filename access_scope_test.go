@@ -0,0 +1,68 @@
+package shopify
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestAccessScopeList(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", "https://fooshop.myshopify.com/admin/oauth/access_scopes.json",
+		httpmock.NewBytesResponder(200, loadFixture("access_scopes.json")))
+
+	scopes, err := client.AccessScope.List(nil)
+	if err != nil {
+		t.Errorf("AccessScope.List returned error: %v", err)
+	}
+
+	expected := []AccessScope{{Handle: "write_products"}, {Handle: "read_orders"}}
+	if !reflect.DeepEqual(scopes, expected) {
+		t.Errorf("AccessScope.List returned %+v, expected %+v", scopes, expected)
+	}
+}
+
+func TestAccessScopeMissing(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", "https://fooshop.myshopify.com/admin/oauth/access_scopes.json",
+		httpmock.NewBytesResponder(200, loadFixture("access_scopes.json")))
+
+	missing, err := client.AccessScope.Missing("read_products,write_orders,read_orders,read_customers")
+	if err != nil {
+		t.Errorf("AccessScope.Missing returned error: %v", err)
+	}
+
+	expected := []string{"read_customers", "write_orders"}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("AccessScope.Missing returned %v, expected %v", missing, expected)
+	}
+}
+
+func TestAppReauthorizeUrl(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", "https://fooshop.myshopify.com/admin/oauth/access_scopes.json",
+		httpmock.NewBytesResponder(200, loadFixture("access_scopes.json")))
+
+	// read_products is implied by write_products
+	u, err := app.ReauthorizeUrl(client, "thenonce")
+	if err != nil || u != "" {
+		t.Errorf("App.ReauthorizeUrl() = %q, %v, expected no url", u, err)
+	}
+
+	a := app
+	a.Scope = "read_products,read_customers"
+	u, err = a.ReauthorizeUrl(client, "thenonce")
+	if err != nil {
+		t.Errorf("App.ReauthorizeUrl() returned error: %v", err)
+	}
+	if expected := a.AuthorizeUrl("fooshop", "thenonce"); u != expected {
+		t.Errorf("App.ReauthorizeUrl() = %s, expected %s", u, expected)
+	}
+}
@@ -0,0 +1,462 @@
+package shopify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Statuses of a bulk operation.
+const (
+	BulkOperationStatusCreated   = "CREATED"
+	BulkOperationStatusRunning   = "RUNNING"
+	BulkOperationStatusCompleted = "COMPLETED"
+	BulkOperationStatusCanceling = "CANCELING"
+	BulkOperationStatusCanceled  = "CANCELED"
+	BulkOperationStatusFailed    = "FAILED"
+	BulkOperationStatusExpired   = "EXPIRED"
+)
+
+const bulkOperationFields = `id status errorCode createdAt completedAt objectCount fileSize url partialDataUrl query`
+
+// BulkOperationService is an interface for running bulk queries through the
+// GraphQL Admin API and reading their results.
+// See: https://shopify.dev/api/usage/bulk-operations/queries
+type BulkOperationService interface {
+	RunQuery(query string) (*BulkOperation, error)
+	Current() (*BulkOperation, error)
+	Cancel(id string) (*BulkOperation, error)
+	Wait(interval time.Duration) (*BulkOperation, error)
+	Download(url string) (io.ReadCloser, error)
+}
+
+// BulkOperationServiceOp handles communication with the bulk operation
+// related queries and mutations of the GraphQL Admin API.
+type BulkOperationServiceOp struct {
+	client *Client
+}
+
+// BulkOperation represents a Shopify bulk operation
+type BulkOperation struct {
+	ID             string     `json:"id"`
+	Status         string     `json:"status"`
+	ErrorCode      string     `json:"errorCode"`
+	CreatedAt      *time.Time `json:"createdAt"`
+	CompletedAt    *time.Time `json:"completedAt"`
+	ObjectCount    string     `json:"objectCount"`
+	FileSize       string     `json:"fileSize"`
+	URL            string     `json:"url"`
+	PartialDataURL string     `json:"partialDataUrl"`
+	Query          string     `json:"query"`
+}
+
+// Done reports whether the bulk operation has stopped running.
+func (o BulkOperation) Done() bool {
+	switch o.Status {
+	case BulkOperationStatusCompleted, BulkOperationStatusCanceled, BulkOperationStatusFailed, BulkOperationStatusExpired:
+		return true
+	}
+	return false
+}
+
+type bulkOperationPayload struct {
+	BulkOperation *BulkOperation    `json:"bulkOperation"`
+	UserErrors    GraphQLUserErrors `json:"userErrors"`
+}
+
+// RunQuery submits query as a bulk operation. Shopify runs one bulk
+// operation per shop at a time, use Wait to poll until it is done.
+func (s *BulkOperationServiceOp) RunQuery(query string) (*BulkOperation, error) {
+	mutation := `mutation($query: String!) { bulkOperationRunQuery(query: $query) { bulkOperation { ` +
+		bulkOperationFields + ` } userErrors { field message } } }`
+
+	resp := struct {
+		BulkOperationRunQuery bulkOperationPayload `json:"bulkOperationRunQuery"`
+	}{}
+	_, err := s.client.GraphQL.Query(mutation, map[string]interface{}{"query": query}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.BulkOperationRunQuery.UserErrors) > 0 {
+		return nil, resp.BulkOperationRunQuery.UserErrors
+	}
+	return resp.BulkOperationRunQuery.BulkOperation, nil
+}
+
+// Current returns the most recent bulk operation of the shop, nil if there
+// is none.
+func (s *BulkOperationServiceOp) Current() (*BulkOperation, error) {
+	query := `{ currentBulkOperation { ` + bulkOperationFields + ` } }`
+
+	resp := struct {
+		CurrentBulkOperation *BulkOperation `json:"currentBulkOperation"`
+	}{}
+	_, err := s.client.GraphQL.Query(query, nil, &resp)
+	return resp.CurrentBulkOperation, err
+}
+
+// Cancel starts cancelling the bulk operation with the given id.
+func (s *BulkOperationServiceOp) Cancel(id string) (*BulkOperation, error) {
+	mutation := `mutation($id: ID!) { bulkOperationCancel(id: $id) { bulkOperation { ` +
+		bulkOperationFields + ` } userErrors { field message } } }`
+
+	resp := struct {
+		BulkOperationCancel bulkOperationPayload `json:"bulkOperationCancel"`
+	}{}
+	_, err := s.client.GraphQL.Query(mutation, map[string]interface{}{"id": id}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.BulkOperationCancel.UserErrors) > 0 {
+		return nil, resp.BulkOperationCancel.UserErrors
+	}
+	return resp.BulkOperationCancel.BulkOperation, nil
+}
+
+// Wait polls the current bulk operation every interval until it is done and
+// returns it. Polling stops when the client's context is done.
+func (s *BulkOperationServiceOp) Wait(interval time.Duration) (*BulkOperation, error) {
+	for {
+		op, err := s.Current()
+		if err != nil {
+			return nil, err
+		}
+		if op == nil || op.Done() {
+			return op, nil
+		}
+
+		s.client.log.Debugf("bulk operation %s is %s, waiting %s", op.ID, op.Status, interval.String())
+		if err := sleepContext(s.client.context(), interval); err != nil {
+			return op, err
+		}
+	}
+}
+
+// Download opens the JSONL result found at the url of a completed bulk
+// operation. The caller must close the returned reader, see
+// DecodeBulkOperationObjects to read it.
+func (s *BulkOperationServiceOp) Download(url string) (io.ReadCloser, error) {
+	// the url is signed, no shop credentials are sent along
+	req, err := http.NewRequestWithContext(s.client.context(), "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, ResponseError{
+			Status:  resp.StatusCode,
+			Message: http.StatusText(resp.StatusCode),
+		}
+	}
+	return resp.Body, nil
+}
+
+// BulkOperationObject is a single line of the JSONL result of a bulk
+// operation. Nested connections are not nested in the result, instead each
+// child is a line of its own pointing to its parent through ParentID.
+type BulkOperationObject struct {
+	// Global id of the object, e.g. gid://shopify/Product/1
+	ID string
+	// Global id of the parent object, empty for top level objects
+	ParentID string
+	// The line as returned by Shopify
+	Raw json.RawMessage
+}
+
+// Type returns the object type found in the global id, e.g. "Product".
+func (o BulkOperationObject) Type() string {
+	typ, _ := parseGlobalID(o.ID)
+	return typ
+}
+
+// Decode decodes the object into v, which is usually one of the REST
+// resources of this package. GraphQL field names are converted to their REST
+// counterparts, e.g. bodyHtml becomes body_html, and global ids are converted
+// to numeric ids. The global id itself is kept as admin_graphql_api_id.
+func (o BulkOperationObject) Decode(v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(o.Raw))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return err
+	}
+
+	js, err := json.Marshal(restifyGraphQLValue(value))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(js, v)
+}
+
+// DecodeBulkOperationObjects reads the JSONL result of a bulk operation and
+// calls fn for every line, without holding the result in memory.
+func DecodeBulkOperationObjects(r io.Reader, fn func(BulkOperationObject) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		ids := struct {
+			ID       string `json:"id"`
+			ParentID string `json:"__parentId"`
+		}{}
+		if err := json.Unmarshal(raw, &ids); err != nil {
+			return err
+		}
+
+		err = fn(BulkOperationObject{ID: ids.ID, ParentID: ids.ParentID, Raw: raw})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// DecodeBulkProducts reads the JSONL result of a bulk query for products and
+// calls fn once per product. Variants, images and metafields of the product,
+// as well as metafields of its variants, are attached to it.
+//
+// Shopify writes child objects right after their parent, so each product is
+// complete once the next product starts.
+func DecodeBulkProducts(r io.Reader, fn func(Product) error) error {
+	var product *Product
+	variants := map[string]int{}
+
+	flush := func() error {
+		if product == nil {
+			return nil
+		}
+		err := fn(*product)
+		product = nil
+		variants = map[string]int{}
+		return err
+	}
+
+	err := DecodeBulkOperationObjects(r, func(o BulkOperationObject) error {
+		if o.ParentID == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			if o.Type() != "Product" {
+				return nil
+			}
+			product = new(Product)
+			return o.Decode(product)
+		}
+
+		if product == nil {
+			return bulkOrphanError(o)
+		}
+
+		if o.ParentID != product.AdminGraphqlAPIID {
+			i, ok := variants[o.ParentID]
+			if !ok {
+				return bulkOrphanError(o)
+			}
+			if o.Type() == "Metafield" {
+				metafield := Metafield{}
+				if err := o.Decode(&metafield); err != nil {
+					return err
+				}
+				product.Variants[i].Metafields = append(product.Variants[i].Metafields, metafield)
+			}
+			return nil
+		}
+
+		switch o.Type() {
+		case "ProductVariant":
+			variant := Variant{ProductID: product.ID}
+			if err := o.Decode(&variant); err != nil {
+				return err
+			}
+			variants[o.ID] = len(product.Variants)
+			product.Variants = append(product.Variants, variant)
+		case "ProductImage":
+			image := Image{ProductID: product.ID}
+			if err := o.Decode(&image); err != nil {
+				return err
+			}
+			product.Images = append(product.Images, image)
+		case "Metafield":
+			metafield := Metafield{}
+			if err := o.Decode(&metafield); err != nil {
+				return err
+			}
+			product.Metafields = append(product.Metafields, metafield)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// DecodeBulkOrders reads the JSONL result of a bulk query for orders and
+// calls fn once per order. Line items and metafields of the order are
+// attached to it.
+//
+// Shopify writes child objects right after their parent, so each order is
+// complete once the next order starts.
+func DecodeBulkOrders(r io.Reader, fn func(Order) error) error {
+	var order *Order
+	var orderID string
+
+	flush := func() error {
+		if order == nil {
+			return nil
+		}
+		err := fn(*order)
+		order = nil
+		return err
+	}
+
+	err := DecodeBulkOperationObjects(r, func(o BulkOperationObject) error {
+		if o.ParentID == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			if o.Type() != "Order" {
+				return nil
+			}
+			order = new(Order)
+			orderID = o.ID
+			return o.Decode(order)
+		}
+
+		if order == nil || o.ParentID != orderID {
+			return bulkOrphanError(o)
+		}
+
+		switch o.Type() {
+		case "LineItem":
+			lineItem := LineItem{}
+			if err := o.Decode(&lineItem); err != nil {
+				return err
+			}
+			order.LineItems = append(order.LineItems, lineItem)
+		case "Metafield":
+			metafield := Metafield{}
+			if err := o.Decode(&metafield); err != nil {
+				return err
+			}
+			order.Metafields = append(order.Metafields, metafield)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+func bulkOrphanError(o BulkOperationObject) error {
+	return fmt.Errorf("bulk operation object %s does not follow its parent %s", o.ID, o.ParentID)
+}
+
+// parseGlobalID splits a global id like gid://shopify/Product/1 into its type
+// and numeric id.
+func parseGlobalID(gid string) (string, int64) {
+	if !strings.HasPrefix(gid, "gid://shopify/") {
+		return "", 0
+	}
+	parts := strings.Split(strings.TrimPrefix(gid, "gid://shopify/"), "/")
+	if len(parts) != 2 {
+		return "", 0
+	}
+	// strip any query, e.g. gid://shopify/LineItem/1?foo=bar
+	id, _ := strconv.ParseInt(strings.SplitN(parts[1], "?", 2)[0], 10, 64)
+	return parts[0], id
+}
+
+// restifyGraphQLValue converts the keys of GraphQL objects to snake case and
+// global ids to numeric ids, dropping the keys only used by bulk operations.
+// Tags, a list in GraphQL, are joined into the comma separated string of the
+// REST resources.
+func restifyGraphQLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			if strings.HasPrefix(key, "__") {
+				continue
+			}
+
+			key = snakeCase(key)
+			if gid, ok := elem.(string); ok && (key == "id" || strings.HasSuffix(key, "_id")) {
+				if _, id := parseGlobalID(gid); id != 0 {
+					if key == "id" {
+						m["admin_graphql_api_id"] = gid
+					}
+					m[key] = id
+					continue
+				}
+			}
+			if tags, ok := elem.([]interface{}); ok && key == "tags" {
+				if joined, ok := joinGraphQLTags(tags); ok {
+					m[key] = joined
+					continue
+				}
+			}
+			m[key] = restifyGraphQLValue(elem)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, elem := range v {
+			s[i] = restifyGraphQLValue(elem)
+		}
+		return s
+	}
+	return value
+}
+
+// joinGraphQLTags joins a list of tags the way REST resources format them,
+// it reports false when the list holds anything but strings.
+func joinGraphQLTags(tags []interface{}) (string, bool) {
+	s := make([]string, len(tags))
+	for i, tag := range tags {
+		str, ok := tag.(string)
+		if !ok {
+			return "", false
+		}
+		s[i] = str
+	}
+	return strings.Join(s, ", "), true
+}
+
+// snakeCase converts a camel case GraphQL field name, e.g. bodyHtml, to its
+// snake case REST counterpart, e.g. body_html.
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,203 @@
+package shopify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/shopspring/decimal"
+)
+
+func TestBulkOperationRunQuery(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/graphql.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			sent := struct {
+				Query     string            `json:"query"`
+				Variables map[string]string `json:"variables"`
+			}{}
+			json.Unmarshal(body, &sent)
+			if !strings.Contains(sent.Query, "bulkOperationRunQuery") || sent.Variables["query"] != "{ products { edges { node { id } } } }" {
+				t.Errorf("BulkOperation.RunQuery sent %s", body)
+			}
+			return httpmock.NewStringResponse(200, `{"data":{"bulkOperationRunQuery":{"bulkOperation":{"id":"gid://shopify/BulkOperation/1","status":"CREATED"},"userErrors":[]}}}`), nil
+		})
+
+	op, err := client.BulkOperation.RunQuery("{ products { edges { node { id } } } }")
+	if err != nil {
+		t.Fatalf("BulkOperation.RunQuery returned error: %v", err)
+	}
+
+	if op.ID != "gid://shopify/BulkOperation/1" || op.Status != BulkOperationStatusCreated {
+		t.Errorf("BulkOperation.RunQuery returned %+v", op)
+	}
+}
+
+func TestBulkOperationRunQueryUserErrors(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/graphql.json", client.pathPrefix),
+		httpmock.NewStringResponder(200, `{"data":{"bulkOperationRunQuery":{"bulkOperation":null,"userErrors":[{"field":["query"],"message":"Invalid bulk query"}]}}}`))
+
+	_, err := client.BulkOperation.RunQuery("{ shop { name } }")
+	if _, ok := err.(GraphQLUserErrors); !ok || err.Error() != "query: Invalid bulk query" {
+		t.Errorf("BulkOperation.RunQuery returned error %#v", err)
+	}
+}
+
+func TestBulkOperationWait(t *testing.T) {
+	setup()
+	defer teardown()
+
+	polls := 0
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/graphql.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			polls++
+			status := BulkOperationStatusRunning
+			url := ""
+			if polls == 3 {
+				status = BulkOperationStatusCompleted
+				url = "https://storage.googleapis.com/result.jsonl"
+			}
+			return httpmock.NewStringResponse(200, fmt.Sprintf(
+				`{"data":{"currentBulkOperation":{"id":"gid://shopify/BulkOperation/1","status":%q,"url":%q}}}`, status, url)), nil
+		})
+
+	op, err := client.BulkOperation.Wait(0)
+	if err != nil {
+		t.Fatalf("BulkOperation.Wait returned error: %v", err)
+	}
+
+	if polls != 3 {
+		t.Errorf("BulkOperation.Wait polled %d times, expected 3", polls)
+	}
+	if !op.Done() || op.URL != "https://storage.googleapis.com/result.jsonl" {
+		t.Errorf("BulkOperation.Wait returned %+v", op)
+	}
+}
+
+func TestBulkOperationDownload(t *testing.T) {
+	setup()
+	defer teardown()
+
+	url := "https://storage.googleapis.com/result.jsonl"
+	httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("X-Shopify-Access-Token") != "" {
+			t.Errorf("BulkOperation.Download sent the access token to %s", url)
+		}
+		return httpmock.NewBytesResponse(200, loadFixture("bulk_orders.jsonl")), nil
+	})
+
+	body, err := client.BulkOperation.Download(url)
+	if err != nil {
+		t.Fatalf("BulkOperation.Download returned error: %v", err)
+	}
+	defer body.Close()
+
+	lines := 0
+	err = DecodeBulkOperationObjects(body, func(o BulkOperationObject) error {
+		lines++
+		return nil
+	})
+	if err != nil {
+		t.Errorf("DecodeBulkOperationObjects returned error: %v", err)
+	}
+	if lines != 4 {
+		t.Errorf("DecodeBulkOperationObjects read %d lines, expected 4", lines)
+	}
+
+	httpmock.RegisterResponder("GET", url+"?expired", httpmock.NewStringResponder(403, "<Error/>"))
+	if _, err = client.BulkOperation.Download(url + "?expired"); err == nil {
+		t.Errorf("BulkOperation.Download expected error for expired url")
+	}
+}
+
+func TestDecodeBulkProducts(t *testing.T) {
+	var products []Product
+	err := DecodeBulkProducts(bytes.NewReader(loadFixture("bulk_products.jsonl")), func(p Product) error {
+		products = append(products, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeBulkProducts returned error: %v", err)
+	}
+
+	if len(products) != 2 {
+		t.Fatalf("DecodeBulkProducts returned %d products, expected 2", len(products))
+	}
+
+	p := products[0]
+	if p.ID != 1 || p.AdminGraphqlAPIID != "gid://shopify/Product/1" || p.BodyHTML != "<strong>Good snowboard!</strong>" || p.ProductType != "Snowboard" {
+		t.Errorf("DecodeBulkProducts returned product %+v", p)
+	}
+	if p.Tags != "Barnes & Noble, Big Air" || products[1].Tags != "" {
+		t.Errorf("DecodeBulkProducts returned tags %q and %q, expected \"Barnes & Noble, Big Air\" and none", p.Tags, products[1].Tags)
+	}
+	if p.CreatedAt == nil {
+		t.Errorf("DecodeBulkProducts did not decode created_at")
+	}
+
+	if len(p.Variants) != 2 || len(p.Images) != 1 {
+		t.Fatalf("DecodeBulkProducts returned %d variants and %d images, expected 2 and 1", len(p.Variants), len(p.Images))
+	}
+
+	v := p.Variants[0]
+	price := decimal.NewFromFloat(10)
+	if v.ID != 11 || v.ProductID != 1 || v.Sku != "BCF-151-S" || v.InventoryQuantity != 5 || !v.Price.Equal(price) {
+		t.Errorf("DecodeBulkProducts returned variant %+v", v)
+	}
+	if len(v.Metafields) != 1 || v.Metafields[0].Key != "bin" {
+		t.Errorf("DecodeBulkProducts returned variant metafields %+v", v.Metafields)
+	}
+
+	if p.Images[0].ID != 13 || p.Images[0].Src != "https://cdn.shopify.com/burton.jpg" {
+		t.Errorf("DecodeBulkProducts returned image %+v", p.Images[0])
+	}
+
+	if len(products[1].Metafields) != 1 || products[1].Metafields[0].ID != 21 {
+		t.Errorf("DecodeBulkProducts returned metafields %+v", products[1].Metafields)
+	}
+}
+
+func TestDecodeBulkProductsOrphan(t *testing.T) {
+	jsonl := `{"id":"gid://shopify/ProductVariant/11","__parentId":"gid://shopify/Product/1"}`
+	err := DecodeBulkProducts(strings.NewReader(jsonl), func(p Product) error { return nil })
+	if err == nil {
+		t.Errorf("DecodeBulkProducts expected error for child without parent")
+	}
+}
+
+func TestDecodeBulkOrders(t *testing.T) {
+	var orders []Order
+	err := DecodeBulkOrders(bytes.NewReader(loadFixture("bulk_orders.jsonl")), func(o Order) error {
+		orders = append(orders, o)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeBulkOrders returned error: %v", err)
+	}
+
+	if len(orders) != 2 {
+		t.Fatalf("DecodeBulkOrders returned %d orders, expected 2", len(orders))
+	}
+
+	o := orders[0]
+	if o.ID != 1 || o.Name != "#1001" || o.Email != "bob@example.com" || o.Tags != "wholesale, priority" {
+		t.Errorf("DecodeBulkOrders returned order %+v", o)
+	}
+	if len(o.LineItems) != 2 || o.LineItems[1].ID != 12 || o.LineItems[1].Quantity != 2 || o.LineItems[1].SKU != "BCF-151-M" {
+		t.Errorf("DecodeBulkOrders returned line items %+v", o.LineItems)
+	}
+	if orders[1].Name != "#1002" || len(orders[1].LineItems) != 0 {
+		t.Errorf("DecodeBulkOrders returned order %+v", orders[1])
+	}
+}
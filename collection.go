@@ -15,6 +15,7 @@ type CollectionService interface {
 	Get(collectionID int64, options interface{}) (*Collection, error)
 	ListProducts(collectionID int64, options interface{}) ([]Product, error)
 	ListProductsWithPagination(collectionID int64, options interface{}) ([]Product, *Pagination, error)
+	ListAllProducts(collectionID int64, options interface{}, fn func([]Product) error) error
 }
 
 // CollectionServiceOp handles communication with the collection related methods of
@@ -80,3 +81,15 @@ func (s *CollectionServiceOp) ListProductsWithPagination(collectionID int64, opt
 
 	return resource.Products, pagination, nil
 }
+
+// ListAllProducts walks every page of products for a collection, calling fn
+// with each page. See Client.ListAll.
+func (s *CollectionServiceOp) ListAllProducts(collectionID int64, options interface{}, fn func([]Product) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		products, pagination, err := s.ListProductsWithPagination(collectionID, options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(products)
+	})
+}
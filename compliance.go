@@ -0,0 +1,89 @@
+package shopify
+
+// Mandatory compliance webhook topics, which every public app must handle.
+// See: https://shopify.dev/apps/webhooks/configuration/mandatory-webhooks
+const (
+	TopicCustomersDataRequest = "customers/data_request"
+	TopicCustomersRedact      = "customers/redact"
+	TopicShopRedact           = "shop/redact"
+)
+
+// ComplianceCustomer is the customer a compliance request is about.
+type ComplianceCustomer struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// CustomersDataRequest is the payload of the customers/data_request topic,
+// sent when a customer asks the shop for their data.
+type CustomersDataRequest struct {
+	ShopID          int64              `json:"shop_id"`
+	ShopDomain      string             `json:"shop_domain"`
+	OrdersRequested []int64            `json:"orders_requested"`
+	Customer        ComplianceCustomer `json:"customer"`
+	DataRequest     struct {
+		ID int64 `json:"id"`
+	} `json:"data_request"`
+}
+
+// CustomersRedact is the payload of the customers/redact topic, sent when
+// the shop asks for the data of a customer to be deleted.
+type CustomersRedact struct {
+	ShopID         int64              `json:"shop_id"`
+	ShopDomain     string             `json:"shop_domain"`
+	Customer       ComplianceCustomer `json:"customer"`
+	OrdersToRedact []int64            `json:"orders_to_redact"`
+}
+
+// ShopRedact is the payload of the shop/redact topic, sent 48 hours after a
+// shop uninstalled the app for its data to be deleted.
+type ShopRedact struct {
+	ShopID     int64  `json:"shop_id"`
+	ShopDomain string `json:"shop_domain"`
+}
+
+// ComplianceProcessor processes the mandatory compliance webhooks. Returning
+// an error answers the request with 500 Internal Server Error, which makes
+// Shopify retry the delivery.
+type ComplianceProcessor interface {
+	CustomersDataRequest(r CustomersDataRequest) error
+	CustomersRedact(r CustomersRedact) error
+	ShopRedact(r ShopRedact) error
+}
+
+// NewComplianceHandler returns a WebhookHandler passing the mandatory
+// compliance webhooks to processor. Like every WebhookHandler it answers
+// requests without a valid HMAC with 401 Unauthorized.
+//
+//	http.Handle("/webhooks/compliance", shopify.NewComplianceHandler(app, processor))
+func NewComplianceHandler(app App, processor ComplianceProcessor) *WebhookHandler {
+	h := NewWebhookHandler(app)
+	h.OnCompliance(processor)
+	return h
+}
+
+// OnCompliance registers processor for the mandatory compliance topics.
+func (h *WebhookHandler) OnCompliance(processor ComplianceProcessor) {
+	h.Handle(TopicCustomersDataRequest, func(d WebhookDelivery) error {
+		r := CustomersDataRequest{}
+		if err := d.Decode(&r); err != nil {
+			return err
+		}
+		return processor.CustomersDataRequest(r)
+	})
+	h.Handle(TopicCustomersRedact, func(d WebhookDelivery) error {
+		r := CustomersRedact{}
+		if err := d.Decode(&r); err != nil {
+			return err
+		}
+		return processor.CustomersRedact(r)
+	})
+	h.Handle(TopicShopRedact, func(d WebhookDelivery) error {
+		r := ShopRedact{}
+		if err := d.Decode(&r); err != nil {
+			return err
+		}
+		return processor.ShopRedact(r)
+	})
+}
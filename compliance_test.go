@@ -0,0 +1,110 @@
+package shopify
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type testComplianceProcessor struct {
+	dataRequests []CustomersDataRequest
+	redacts      []CustomersRedact
+	shopRedacts  []ShopRedact
+	err          error
+}
+
+func (p *testComplianceProcessor) CustomersDataRequest(r CustomersDataRequest) error {
+	p.dataRequests = append(p.dataRequests, r)
+	return p.err
+}
+
+func (p *testComplianceProcessor) CustomersRedact(r CustomersRedact) error {
+	p.redacts = append(p.redacts, r)
+	return p.err
+}
+
+func (p *testComplianceProcessor) ShopRedact(r ShopRedact) error {
+	p.shopRedacts = append(p.shopRedacts, r)
+	return p.err
+}
+
+func TestComplianceHandler(t *testing.T) {
+	setup()
+	defer teardown()
+
+	processor := &testComplianceProcessor{}
+	handler := NewComplianceHandler(app, processor)
+
+	requests := []struct {
+		topic string
+		body  string
+	}{
+		{TopicCustomersDataRequest, `{"shop_id":954889,"shop_domain":"fooshop.myshopify.com","orders_requested":[299938,280263],"customer":{"id":191167,"email":"john@example.com","phone":"555-625-1199"},"data_request":{"id":9999}}`},
+		{TopicCustomersRedact, `{"shop_id":954889,"shop_domain":"fooshop.myshopify.com","customer":{"id":191167,"email":"john@example.com","phone":"555-625-1199"},"orders_to_redact":[299938,280263]}`},
+		{TopicShopRedact, `{"shop_id":954889,"shop_domain":"fooshop.myshopify.com"}`},
+	}
+	for _, r := range requests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newWebhookRequest(r.topic, r.body))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s answered %d, expected 200", r.topic, w.Code)
+		}
+	}
+
+	customer := ComplianceCustomer{ID: 191167, Email: "john@example.com", Phone: "555-625-1199"}
+	dataRequest := CustomersDataRequest{ShopID: 954889, ShopDomain: "fooshop.myshopify.com", OrdersRequested: []int64{299938, 280263}, Customer: customer}
+	dataRequest.DataRequest.ID = 9999
+	if !reflect.DeepEqual(processor.dataRequests, []CustomersDataRequest{dataRequest}) {
+		t.Errorf("CustomersDataRequest received %+v, expected %+v", processor.dataRequests, dataRequest)
+	}
+	redact := CustomersRedact{ShopID: 954889, ShopDomain: "fooshop.myshopify.com", Customer: customer, OrdersToRedact: []int64{299938, 280263}}
+	if !reflect.DeepEqual(processor.redacts, []CustomersRedact{redact}) {
+		t.Errorf("CustomersRedact received %+v, expected %+v", processor.redacts, redact)
+	}
+	shopRedact := ShopRedact{ShopID: 954889, ShopDomain: "fooshop.myshopify.com"}
+	if !reflect.DeepEqual(processor.shopRedacts, []ShopRedact{shopRedact}) {
+		t.Errorf("ShopRedact received %+v, expected %+v", processor.shopRedacts, shopRedact)
+	}
+}
+
+func TestComplianceHandlerStatus(t *testing.T) {
+	setup()
+	defer teardown()
+
+	processor := &testComplianceProcessor{}
+	handler := NewComplianceHandler(app, processor)
+
+	unsigned := newWebhookRequest(TopicShopRedact, `{"shop_id":954889}`)
+	unsigned.Header.Del("X-Shopify-Hmac-Sha256")
+	forged := newWebhookRequest(TopicShopRedact, `{"shop_id":954889}`)
+	forged.Header.Set("X-Shopify-Hmac-Sha256", "hMTq0K2x7oyOjoBwGYeTj5oxfnaVYXzbanUG9aajpKI=")
+
+	cases := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"unsigned", unsigned, http.StatusUnauthorized},
+		{"forged", forged, http.StatusUnauthorized},
+		{"malformed", newWebhookRequest(TopicCustomersRedact, `{"customer":`), http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, c.req)
+		if w.Code != c.status {
+			t.Errorf("%s request answered %d, expected %d", c.name, w.Code, c.status)
+		}
+	}
+	if len(processor.redacts) > 0 || len(processor.shopRedacts) > 0 {
+		t.Errorf("processor called for rejected requests")
+	}
+
+	processor.err = errors.New("database unavailable")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newWebhookRequest(TopicShopRedact, `{"shop_id":954889}`))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("failed request answered %d, expected 500", w.Code)
+	}
+}
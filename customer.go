@@ -17,6 +17,7 @@ const customersResourceName = "customers"
 type CustomerService interface {
 	List(interface{}) ([]Customer, error)
 	ListWithPagination(interface{}) ([]Customer, *Pagination, error)
+	ListAll(interface{}, func([]Customer) error) error
 	Count(interface{}) (int, error)
 	Get(int64, interface{}) (*Customer, error)
 	Search(interface{}) ([]Customer, error)
@@ -117,6 +118,17 @@ func (s *CustomerServiceOp) ListWithPagination(options interface{}) ([]Customer,
 	return resource.Customers, pagination, nil
 }
 
+// ListAll walks every page of customers, calling fn with each page. See Client.ListAll.
+func (s *CustomerServiceOp) ListAll(options interface{}, fn func([]Customer) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		customers, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(customers)
+	})
+}
+
 // Count customers
 func (s *CustomerServiceOp) Count(options interface{}) (int, error) {
 	path := fmt.Sprintf("%s/count.json", customersBasePath)
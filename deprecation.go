@@ -0,0 +1,149 @@
+package shopify
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// matches the version prefix of a request path
+	apiPathPrefixRegex = regexp.MustCompile(`^/?admin/(api/[^/]+/)?`)
+	// matches the ids in a request path
+	pathIDRegex = regexp.MustCompile(`/[0-9]+(/|\.json$|$)`)
+)
+
+// DeprecatedCall describes a request Shopify answered with the
+// X-Shopify-API-Deprecated-Reason header, see WithDeprecationHook.
+type DeprecatedCall struct {
+	Method string
+	// Endpoint is the path of the request without api version prefix and with
+	// ids replaced by ":id", e.g. "products/:id/images.json".
+	Endpoint string
+	// Reason links to Shopify's changelog entry about the deprecation.
+	Reason string
+	// APIVersion the request was served with.
+	APIVersion string
+}
+
+// key identifies the endpoint of the call.
+func (d DeprecatedCall) key() string {
+	return d.Method + " " + d.Endpoint
+}
+
+// DeprecatedEndpoint aggregates the deprecated calls made to an endpoint, see
+// DeprecationCollector.
+type DeprecatedEndpoint struct {
+	DeprecatedCall
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// DeprecationCollector aggregates the deprecated endpoints used by clients,
+// to plan api version upgrades. It is safe for concurrent use.
+//
+//	collector := shopify.NewDeprecationCollector()
+//	client := shopify.NewClient(app, "shopname", "token",
+//		shopify.WithDeprecationHook(collector.Record))
+//	...
+//	for _, endpoint := range collector.Endpoints() {
+//		log.Printf("%s %s: %s", endpoint.Method, endpoint.Endpoint, endpoint.Reason)
+//	}
+type DeprecationCollector struct {
+	mu        sync.Mutex
+	endpoints map[string]*DeprecatedEndpoint
+}
+
+// NewDeprecationCollector returns an empty DeprecationCollector.
+func NewDeprecationCollector() *DeprecationCollector {
+	return &DeprecationCollector{endpoints: map[string]*DeprecatedEndpoint{}}
+}
+
+// Record adds a deprecated call, it is meant to be passed to
+// WithDeprecationHook.
+func (c *DeprecationCollector) Record(call DeprecatedCall) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	endpoint, ok := c.endpoints[call.key()]
+	if !ok {
+		endpoint = &DeprecatedEndpoint{DeprecatedCall: call, FirstSeen: now}
+		c.endpoints[call.key()] = endpoint
+	}
+	endpoint.Count++
+	endpoint.LastSeen = now
+	// keep the latest reason and version
+	endpoint.Reason = call.Reason
+	endpoint.APIVersion = call.APIVersion
+}
+
+// Endpoints returns the deprecated endpoints recorded so far, sorted by
+// endpoint and method.
+func (c *DeprecationCollector) Endpoints() []DeprecatedEndpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	endpoints := make([]DeprecatedEndpoint, 0, len(c.endpoints))
+	for _, endpoint := range c.endpoints {
+		endpoints = append(endpoints, *endpoint)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Endpoint != endpoints[j].Endpoint {
+			return endpoints[i].Endpoint < endpoints[j].Endpoint
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+	return endpoints
+}
+
+// Reset forgets the endpoints recorded so far.
+func (c *DeprecationCollector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endpoints = map[string]*DeprecatedEndpoint{}
+}
+
+// normalizeEndpoint strips the version prefix of a request path and replaces
+// its ids with ":id", so calls to the same endpoint share one key.
+func normalizeEndpoint(path string) string {
+	path = apiPathPrefixRegex.ReplaceAllString(path, "")
+	// ids may follow each other, e.g. products/1/images/2.json
+	for pathIDRegex.MatchString(path) {
+		path = pathIDRegex.ReplaceAllString(path, "/:id$1")
+	}
+	return strings.TrimPrefix(path, "/")
+}
+
+// reportDeprecation logs a deprecated call once per endpoint and passes it to
+// the deprecation hook.
+func (c *Client) reportDeprecation(req *http.Request, meta *Response) {
+	call := DeprecatedCall{
+		Method:     req.Method,
+		Endpoint:   normalizeEndpoint(req.URL.Path),
+		Reason:     meta.DeprecatedReason,
+		APIVersion: meta.APIVersion,
+	}
+
+	c.state.mu.Lock()
+	logged := c.state.deprecations[call.key()]
+	if !logged {
+		if c.state.deprecations == nil {
+			c.state.deprecations = map[string]bool{}
+		}
+		c.state.deprecations[call.key()] = true
+	}
+	c.state.mu.Unlock()
+
+	if !logged {
+		c.log.Warnf("deprecated api call %s: %s", call.key(), call.Reason)
+	}
+
+	if c.deprecationHook != nil {
+		c.deprecationHook(call)
+	}
+}
@@ -0,0 +1,113 @@
+package shopify
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestNormalizeEndpoint(t *testing.T) {
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"/admin/api/2021-01/products.json", "products.json"},
+		{"/admin/api/2021-01/products/123.json", "products/:id.json"},
+		{"/admin/api/unstable/products/123/images/456.json", "products/:id/images/:id.json"},
+		{"/admin/products/123/variants/456", "products/:id/variants/:id"},
+		{"/admin/orders/1/2/3.json", "orders/:id/:id/:id.json"},
+		{"/admin/api/2021-01/shop.json", "shop.json"},
+	}
+
+	for _, c := range cases {
+		if actual := normalizeEndpoint(c.path); actual != c.expected {
+			t.Errorf("normalizeEndpoint(%q) = %q, expected %q", c.path, actual, c.expected)
+		}
+	}
+}
+
+func TestDeprecationCollector(t *testing.T) {
+	collector := NewDeprecationCollector()
+	collector.Record(DeprecatedCall{Method: "GET", Endpoint: "products/:id.json", Reason: "a", APIVersion: "2021-01"})
+	collector.Record(DeprecatedCall{Method: "GET", Endpoint: "orders.json", Reason: "b", APIVersion: "2021-01"})
+	collector.Record(DeprecatedCall{Method: "GET", Endpoint: "products/:id.json", Reason: "c", APIVersion: "2021-04"})
+
+	endpoints := collector.Endpoints()
+	if len(endpoints) != 2 {
+		t.Fatalf("Endpoints() returned %d endpoints, expected 2", len(endpoints))
+	}
+	if endpoints[0].Endpoint != "orders.json" || endpoints[0].Count != 1 {
+		t.Errorf("Endpoints()[0] = %#v", endpoints[0])
+	}
+	if endpoints[1].Endpoint != "products/:id.json" || endpoints[1].Count != 2 || endpoints[1].Reason != "c" || endpoints[1].APIVersion != "2021-04" {
+		t.Errorf("Endpoints()[1] = %#v", endpoints[1])
+	}
+	if endpoints[1].FirstSeen.After(endpoints[1].LastSeen) {
+		t.Errorf("Endpoints()[1] first seen after last seen")
+	}
+
+	collector.Reset()
+	if len(collector.Endpoints()) != 0 {
+		t.Errorf("Reset() left endpoints %#v", collector.Endpoints())
+	}
+}
+
+func TestClientDeprecationReporting(t *testing.T) {
+	setup()
+	defer teardown()
+
+	stderr := &bytes.Buffer{}
+	logger := &LeveledLogger{Level: LevelWarn, stderrOverride: stderr, stdoutOverride: &bytes.Buffer{}}
+	collector := NewDeprecationCollector()
+	testClient := NewClient(app, "fooshop", "abcd", WithVersion(testApiVersion), WithLogger(logger),
+		WithDeprecationHook(collector.Record))
+	httpmock.ActivateNonDefault(testClient.Client)
+
+	reason := "https://shopify.dev/changelog/product-option-deprecated"
+	deprecated := func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(http.StatusOK, `{"product": {"id": 1}}`)
+		resp.Header.Set("X-Shopify-API-Deprecated-Reason", reason)
+		resp.Header.Set("X-Shopify-API-Version", testApiVersion)
+		return resp, nil
+	}
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/products/1.json", testClient.pathPrefix), deprecated)
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/products/2.json", testClient.pathPrefix), deprecated)
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/shop.json", testClient.pathPrefix),
+		httpmock.NewStringResponder(http.StatusOK, `{"shop": {"id": 1}}`))
+
+	for _, id := range []int64{1, 2, 1} {
+		if _, err := testClient.Product.Get(id, nil); err != nil {
+			t.Fatalf("Product.Get returned error: %v", err)
+		}
+	}
+	if _, err := testClient.Shop.Get(nil); err != nil {
+		t.Fatalf("Shop.Get returned error: %v", err)
+	}
+
+	expectedLog := fmt.Sprintf("[WARN] deprecated api call GET products/:id.json: %s\n", reason)
+	if stderr.String() != expectedLog {
+		t.Errorf("expected the deprecation to be logged once %q, logged %q", expectedLog, stderr.String())
+	}
+
+	endpoints := collector.Endpoints()
+	if len(endpoints) != 1 {
+		t.Fatalf("collector recorded %#v, expected one endpoint", endpoints)
+	}
+	expected := DeprecatedCall{Method: "GET", Endpoint: "products/:id.json", Reason: reason, APIVersion: testApiVersion}
+	if endpoints[0].DeprecatedCall != expected || endpoints[0].Count != 3 {
+		t.Errorf("collector recorded %#v, expected %#v 3 times", endpoints[0], expected)
+	}
+
+	// copies of the client share which endpoints were logged
+	stderr.Reset()
+	if _, err := testClient.WithResponse(&Response{}).Product.Get(2, nil); err != nil {
+		t.Fatalf("Product.Get returned error: %v", err)
+	}
+	if strings.Contains(stderr.String(), "deprecated") {
+		t.Errorf("expected no further log, logged %q", stderr.String())
+	}
+}
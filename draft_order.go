@@ -17,6 +17,8 @@ const (
 // See: https://help.shopify.com/api/reference/orders/draftorder
 type DraftOrderService interface {
 	List(interface{}) ([]DraftOrder, error)
+	ListWithPagination(interface{}) ([]DraftOrder, *Pagination, error)
+	ListAll(interface{}, func([]DraftOrder) error) error
 	Count(interface{}) (int, error)
 	Get(int64, interface{}) (*DraftOrder, error)
 	Create(DraftOrder) (*DraftOrder, error)
@@ -134,6 +136,30 @@ func (s *DraftOrderServiceOp) List(options interface{}) ([]DraftOrder, error) {
 	return resource.DraftOrders, err
 }
 
+// ListWithPagination lists draft orders and return pagination to retrieve next/previous results.
+func (s *DraftOrderServiceOp) ListWithPagination(options interface{}) ([]DraftOrder, *Pagination, error) {
+	path := fmt.Sprintf("%s.json", draftOrdersBasePath)
+	resource := new(DraftOrdersResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.DraftOrders, pagination, nil
+}
+
+// ListAll walks every page of draft orders, calling fn with each page. See Client.ListAll.
+func (s *DraftOrderServiceOp) ListAll(options interface{}, fn func([]DraftOrder) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		orders, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(orders)
+	})
+}
+
 // Count draft orders
 func (s *DraftOrderServiceOp) Count(options interface{}) (int, error) {
 	path := fmt.Sprintf("%s/count.json", draftOrdersBasePath)
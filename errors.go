@@ -0,0 +1,157 @@
+package shopify
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matching the response errors of the same status with
+// errors.Is, e.g. errors.Is(err, ErrNotFound).
+var (
+	ErrInvalidToken    = errors.New("invalid api key or access token")
+	ErrPaymentRequired = errors.New("payment required")
+	ErrForbidden       = errors.New("forbidden")
+	ErrNotFound        = errors.New("not found")
+	ErrValidation      = errors.New("validation failed")
+	ErrShopLocked      = errors.New("shop locked")
+	ErrRateLimited     = errors.New("rate limited")
+	ErrServer          = errors.New("server error")
+
+	// ErrTokenRevoked matches the InvalidTokenError of a token that was
+	// revoked, usually because the shop uninstalled the app.
+	ErrTokenRevoked = errors.New("access token revoked")
+)
+
+// statusError returns the sentinel error for a response status, nil if there
+// is none.
+func statusError(status int) error {
+	switch {
+	case status == http.StatusUnauthorized:
+		return ErrInvalidToken
+	case status == http.StatusPaymentRequired:
+		return ErrPaymentRequired
+	case status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case status == http.StatusLocked:
+		return ErrShopLocked
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return nil
+}
+
+// InvalidTokenError is returned for 401 Unauthorized responses, the access
+// token or api key is wrong or has been revoked.
+type InvalidTokenError struct {
+	ResponseError
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e InvalidTokenError) Unwrap() error {
+	return e.ResponseError
+}
+
+// Revoked reports whether Shopify rejected the token itself rather than the
+// request, which happens once the shop uninstalled the app.
+func (e InvalidTokenError) Revoked() bool {
+	return strings.Contains(strings.ToLower(e.Message), "invalid api key or access token")
+}
+
+// Is reports whether the error matches target, ErrTokenRevoked when the token
+// was revoked.
+func (e InvalidTokenError) Is(target error) bool {
+	if target == ErrTokenRevoked {
+		return e.Revoked()
+	}
+	return e.ResponseError.Is(target)
+}
+
+// PaymentRequiredError is returned for 402 Payment Required responses, the
+// shop is frozen until the merchant pays their bill.
+type PaymentRequiredError struct {
+	ResponseError
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e PaymentRequiredError) Unwrap() error {
+	return e.ResponseError
+}
+
+// ForbiddenError is returned for 403 Forbidden responses, usually because
+// the access token lacks the scope the endpoint requires.
+type ForbiddenError struct {
+	ResponseError
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e ForbiddenError) Unwrap() error {
+	return e.ResponseError
+}
+
+// NotFoundError is returned for 404 Not Found responses.
+type NotFoundError struct {
+	ResponseError
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e NotFoundError) Unwrap() error {
+	return e.ResponseError
+}
+
+// ValidationError is returned for 422 Unprocessable Entity responses. Fields
+// maps each invalid field to its error messages, fields of nested objects are
+// keyed on their dotted path, e.g. "line_items.0.quantity".
+type ValidationError struct {
+	ResponseError
+	Fields map[string][]string
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e ValidationError) Unwrap() error {
+	return e.ResponseError
+}
+
+// FieldErrors returns the messages of the field at path and of all fields
+// nested below it, keyed on their path.
+func (e ValidationError) FieldErrors(path string) map[string][]string {
+	errs := map[string][]string{}
+	for field, messages := range e.Fields {
+		if field == path || strings.HasPrefix(field, path+".") {
+			errs[field] = messages
+		}
+	}
+	return errs
+}
+
+// ShopLockedError is returned for 423 Locked responses, the shop is not
+// available.
+type ShopLockedError struct {
+	ResponseError
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e ShopLockedError) Unwrap() error {
+	return e.ResponseError
+}
+
+// ServerError is returned for 5xx responses.
+type ServerError struct {
+	ResponseError
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e ServerError) Unwrap() error {
+	return e.ResponseError
+}
+
+// Unwrap returns the underlying ResponseError.
+func (e RateLimitError) Unwrap() error {
+	return e.ResponseError
+}
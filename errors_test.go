@@ -0,0 +1,239 @@
+package shopify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestCheckResponseErrorTypes(t *testing.T) {
+	cases := []struct {
+		status   int
+		body     string
+		sentinel error
+		expected error
+	}{
+		{
+			http.StatusUnauthorized,
+			`{"errors": "[API] Invalid API key or access token (unrecognized login or wrong password)"}`,
+			ErrInvalidToken,
+			InvalidTokenError{ResponseError{Status: 401, Message: "[API] Invalid API key or access token (unrecognized login or wrong password)"}},
+		},
+		{
+			http.StatusPaymentRequired,
+			`{"errors": "Unavailable Shop"}`,
+			ErrPaymentRequired,
+			PaymentRequiredError{ResponseError{Status: 402, Message: "Unavailable Shop"}},
+		},
+		{
+			http.StatusForbidden,
+			`{"errors": "This action requires merchant approval for read_orders scope."}`,
+			ErrForbidden,
+			ForbiddenError{ResponseError{Status: 403, Message: "This action requires merchant approval for read_orders scope."}},
+		},
+		{
+			http.StatusNotFound,
+			`{"errors": "Not Found"}`,
+			ErrNotFound,
+			NotFoundError{ResponseError{Status: 404, Message: "Not Found"}},
+		},
+		{
+			http.StatusUnprocessableEntity,
+			`{"errors": {"title": ["can't be blank"]}}`,
+			ErrValidation,
+			ValidationError{
+				ResponseError: ResponseError{Status: 422, Message: "title: can't be blank", Errors: []string{"title: can't be blank"}},
+				Fields:        map[string][]string{"title": {"can't be blank"}},
+			},
+		},
+		{
+			http.StatusLocked,
+			`{"errors": "This shop is unavailable"}`,
+			ErrShopLocked,
+			ShopLockedError{ResponseError{Status: 423, Message: "This shop is unavailable"}},
+		},
+		{
+			http.StatusBadGateway,
+			``,
+			ErrServer,
+			ServerError{ResponseError{Status: 502}},
+		},
+		{
+			http.StatusTooManyRequests,
+			`{"errors": "Exceeded 2 calls per second for api client."}`,
+			ErrRateLimited,
+			RateLimitError{ResponseError: ResponseError{Status: 429, Message: "Exceeded 2 calls per second for api client."}},
+		},
+	}
+
+	for _, c := range cases {
+		err := CheckResponseError(httpmock.NewStringResponse(c.status, c.body))
+		if !reflect.DeepEqual(err, c.expected) {
+			t.Errorf("CheckResponseError(%d): expected %#v, actual %#v", c.status, c.expected, err)
+		}
+		if !errors.Is(err, c.sentinel) {
+			t.Errorf("CheckResponseError(%d): expected errors.Is %v", c.status, c.sentinel)
+		}
+		if errors.Is(err, ErrNotFound) != (c.sentinel == ErrNotFound) {
+			t.Errorf("CheckResponseError(%d): unexpected errors.Is ErrNotFound", c.status)
+		}
+
+		var respErr ResponseError
+		if !errors.As(err, &respErr) || respErr.Status != c.status {
+			t.Errorf("CheckResponseError(%d): expected errors.As ResponseError, actual %#v", c.status, respErr)
+		}
+	}
+
+	// statuses without a typed error stay plain
+	err := CheckResponseError(httpmock.NewStringResponse(http.StatusBadRequest, `{"error": "bad request"}`))
+	if _, ok := err.(ResponseError); !ok {
+		t.Errorf("CheckResponseError(400): expected ResponseError, actual %#v", err)
+	}
+	for _, sentinel := range []error{ErrInvalidToken, ErrForbidden, ErrNotFound, ErrValidation, ErrServer} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("CheckResponseError(400): unexpected errors.Is %v", sentinel)
+		}
+	}
+
+	// undecodable 5xx responses are server errors too
+	err = CheckResponseError(httpmock.NewStringResponse(http.StatusInternalServerError, `<html></html>`))
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("CheckResponseError(500): expected errors.Is ErrServer, actual %#v", err)
+	}
+}
+
+func TestInvalidTokenErrorRevoked(t *testing.T) {
+	revoked := CheckResponseError(httpmock.NewStringResponse(http.StatusUnauthorized,
+		`{"errors": "[API] Invalid API key or access token (unrecognized login or wrong password)"}`))
+	if !errors.Is(revoked, ErrTokenRevoked) || !errors.Is(revoked, ErrInvalidToken) {
+		t.Errorf("expected errors.Is ErrTokenRevoked and ErrInvalidToken, actual %#v", revoked)
+	}
+
+	other := CheckResponseError(httpmock.NewStringResponse(http.StatusUnauthorized, `{"errors": "Unauthorized"}`))
+	if errors.Is(other, ErrTokenRevoked) || !errors.Is(other, ErrInvalidToken) {
+		t.Errorf("expected errors.Is ErrInvalidToken only, actual %#v", other)
+	}
+
+	notFound := CheckResponseError(httpmock.NewStringResponse(http.StatusNotFound, `{"errors": "Invalid API key or access token"}`))
+	if errors.Is(notFound, ErrTokenRevoked) {
+		t.Errorf("unexpected errors.Is ErrTokenRevoked for %#v", notFound)
+	}
+}
+
+func TestCheckResponseErrorRequest(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/foo/1", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusForbidden, `{"errors": "This action requires merchant approval for read_orders scope."}`)
+			resp.Header.Set("X-Request-Id", "fc5f9c6f-4aa3-4d4b-9f64-4d0c8e8b0b7a")
+			return resp, nil
+		})
+
+	err := client.Get("foo/1", nil, nil)
+
+	var forbidden ForbiddenError
+	if !errors.As(err, &forbidden) {
+		t.Fatalf("Get(): expected ForbiddenError, actual %#v", err)
+	}
+
+	expected := ResponseError{
+		Status:    http.StatusForbidden,
+		Message:   "This action requires merchant approval for read_orders scope.",
+		RequestID: "fc5f9c6f-4aa3-4d4b-9f64-4d0c8e8b0b7a",
+		Method:    "GET",
+		Path:      fmt.Sprintf("/%s/foo/1", client.pathPrefix),
+	}
+	if !reflect.DeepEqual(forbidden.ResponseError, expected) {
+		t.Errorf("Get(): expected %#v, actual %#v", expected, forbidden.ResponseError)
+	}
+}
+
+func TestCheckResponseErrorGatewayPage(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/foo/1", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusBadGateway, `<html><body>502 Bad Gateway</body></html>`)
+			resp.Header.Set("X-Request-Id", "0b1c5d2e-7f3a-4c8e-9d6b-2a4f8e1c3b5d")
+			return resp, nil
+		})
+
+	err := client.Get("foo/1", nil, nil)
+
+	var serverErr ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("Get(): expected ServerError, actual %#v", err)
+	}
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("Get(): expected errors.Is ErrServer, actual %#v", err)
+	}
+
+	expected := ResponseError{
+		Status:    http.StatusBadGateway,
+		Message:   "Bad Gateway",
+		RequestID: "0b1c5d2e-7f3a-4c8e-9d6b-2a4f8e1c3b5d",
+		Method:    "GET",
+		Path:      fmt.Sprintf("/%s/foo/1", client.pathPrefix),
+	}
+	if !reflect.DeepEqual(serverErr.ResponseError, expected) {
+		t.Errorf("Get(): expected %#v, actual %#v", expected, serverErr.ResponseError)
+	}
+}
+
+func TestValidationErrorFields(t *testing.T) {
+	body := `{"errors": {
+		"email": ["is invalid", "has already been taken"],
+		"line_items": {"0": {"quantity": ["must be greater than 0"]}, "1": {"variant_id": "is not available"}},
+		"shipping_lines": [{"price": ["can't be blank"]}],
+		"customer": null
+	}}`
+
+	err := CheckResponseError(httpmock.NewStringResponse(http.StatusUnprocessableEntity, body))
+
+	var validation ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("CheckResponseError(): expected ValidationError, actual %#v", err)
+	}
+
+	expectedFields := map[string][]string{
+		"email":                   {"is invalid", "has already been taken"},
+		"line_items.0.quantity":   {"must be greater than 0"},
+		"line_items.1.variant_id": {"is not available"},
+		"shipping_lines.0.price":  {"can't be blank"},
+	}
+	if !reflect.DeepEqual(validation.Fields, expectedFields) {
+		t.Errorf("ValidationError.Fields: expected %#v, actual %#v", expectedFields, validation.Fields)
+	}
+
+	expectedErrors := []string{
+		"email: is invalid",
+		"email: has already been taken",
+		"line_items.0.quantity: must be greater than 0",
+		"line_items.1.variant_id: is not available",
+		"shipping_lines.0.price: can't be blank",
+	}
+	if !reflect.DeepEqual(validation.Errors, expectedErrors) {
+		t.Errorf("ValidationError.Errors: expected %#v, actual %#v", expectedErrors, validation.Errors)
+	}
+	if validation.Message != "email: is invalid" {
+		t.Errorf("ValidationError.Message: expected %q, actual %q", "email: is invalid", validation.Message)
+	}
+
+	expectedLineItems := map[string][]string{
+		"line_items.0.quantity":   {"must be greater than 0"},
+		"line_items.1.variant_id": {"is not available"},
+	}
+	if lineItems := validation.FieldErrors("line_items"); !reflect.DeepEqual(lineItems, expectedLineItems) {
+		t.Errorf("ValidationError.FieldErrors(): expected %#v, actual %#v", expectedLineItems, lineItems)
+	}
+	if email := validation.FieldErrors("email"); len(email) != 1 {
+		t.Errorf("ValidationError.FieldErrors(): expected only email, actual %#v", email)
+	}
+}
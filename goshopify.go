@@ -3,7 +3,9 @@ package shopify
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -45,6 +47,10 @@ type App struct {
 	Scope       string
 	Password    string
 	Client      *Client // see GetAccessToken
+
+	// TokenStore persists the tokens obtained by RequestAccessToken, see
+	// NewClientForShop.
+	TokenStore TokenStore
 }
 
 type RateLimitInfo struct {
@@ -53,7 +59,8 @@ type RateLimitInfo struct {
 	RetryAfterSeconds float64
 }
 
-// Client manages communication with the Shopify API.
+// Client manages communication with the Shopify API. It is safe for
+// concurrent use.
 type Client struct {
 	// HTTP client used to communicate with the Shopify API.
 	Client *http.Client
@@ -70,17 +77,36 @@ type Client struct {
 	// URL Prefix, defaults to "admin" see WithVersion
 	pathPrefix string
 
-	// version you're currently using of the api, defaults to "stable"
-	apiVersion string
+	// version you're currently using of the api, defaults to "stable", and
+	// other state updated by requests, shared with copies of the client
+	state *clientState
 
 	// A permanent access token
 	token string
 
 	// max number of retries, defaults to 0 for no retries see WithRetry option
-	retries  int
-	attempts int
+	retries int
+
+	// context used for requests built by NewRequest, see WithContext
+	ctx context.Context
+
+	// throttles outgoing requests, nil for none see WithRateLimiter
+	rateLimiter RateLimiter
+
+	// decides which failed requests are retried, see WithRetryPolicy
+	retryPolicy RetryPolicy
+
+	// records the metadata of responses, see WithResponse
+	response *Response
+
+	// called for every deprecated call, see WithDeprecationHook
+	deprecationHook func(DeprecatedCall)
 
-	RateLimits RateLimitInfo
+	// expiry of the access token, see WithTokenExpiry
+	tokenExpiry time.Time
+
+	// called when the token was revoked, see WithUninstallHook
+	uninstallHook UninstallHook
 
 	// Services used for communicating with the API
 	Product                    ProductService
@@ -113,10 +139,14 @@ type Client struct {
 	DiscountCode               DiscountCodeService
 	PriceRule                  PriceRuleService
 	InventoryItem              InventoryItemService
+	InventoryLevel             InventoryLevelService
 	ShippingZone               ShippingZoneService
 	ProductListing             ProductListingService
 	AbandonedCheckouts         AbandonedCheckoutsService
 	FulfillmentOrder           FulfillmentOrderService
+	GraphQL                    GraphQLService
+	BulkOperation              BulkOperationService
+	AccessScope                AccessScopeService
 }
 
 // A general response error that follows a similar layout to Shopify's response
@@ -125,6 +155,13 @@ type ResponseError struct {
 	Status  int
 	Message string
 	Errors  []string
+
+	// RequestID is the X-Request-Id of the response, Shopify support asks
+	// for it when investigating a failed request.
+	RequestID string
+	// Method and Path of the request that failed.
+	Method string
+	Path   string
 }
 
 // GetStatus returns http  response status
@@ -157,18 +194,36 @@ func (e ResponseError) Error() string {
 	return "Unknown Error"
 }
 
+// Is reports whether target is the sentinel error of the response status,
+// e.g. errors.Is(err, ErrNotFound).
+func (e ResponseError) Is(target error) bool {
+	sentinel := statusError(e.Status)
+	return sentinel != nil && sentinel == target
+}
+
 // ResponseDecodingError occurs when the response body from Shopify could
 // not be parsed.
 type ResponseDecodingError struct {
 	Body    []byte
 	Message string
 	Status  int
+
+	// RequestID, Method and Path of the failed request, see ResponseError.
+	RequestID string
+	Method    string
+	Path      string
 }
 
 func (e ResponseDecodingError) Error() string {
 	return e.Message
 }
 
+// Is reports whether target is the sentinel error of the response status.
+func (e ResponseDecodingError) Is(target error) bool {
+	sentinel := statusError(e.Status)
+	return sentinel != nil && sentinel == target
+}
+
 // An error specific to a rate-limiting response. Embeds the ResponseError to
 // allow consumers to handle it the same was a normal ResponseError.
 type RateLimitError struct {
@@ -181,6 +236,12 @@ type RateLimitError struct {
 // specified without a preceding slash. If specified, the value pointed to by
 // body is JSON encoded and included as the request body.
 func (c *Client) NewRequest(method, relPath string, body, options interface{}) (*http.Request, error) {
+	return c.NewRequestWithContext(c.context(), method, relPath, body, options)
+}
+
+// NewRequestWithContext is like NewRequest but the returned request is bound
+// to ctx, so cancelling ctx aborts the request and any retry backoff.
+func (c *Client) NewRequestWithContext(ctx context.Context, method, relPath string, body, options interface{}) (*http.Request, error) {
 	rel, err := url.Parse(relPath)
 	if err != nil {
 		return nil, err
@@ -214,7 +275,7 @@ func (c *Client) NewRequest(method, relPath string, body, options interface{}) (
 		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), bytes.NewBuffer(js))
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewBuffer(js))
 	if err != nil {
 		return nil, err
 	}
@@ -255,10 +316,22 @@ func NewClient(app App, shopName, token string, opts ...Option) *Client {
 		app:        app,
 		baseURL:    baseURL,
 		token:      token,
-		apiVersion: defaultApiVersion,
+		state:      &clientState{apiVersion: defaultApiVersion},
 		pathPrefix: defaultApiPathPrefix,
 	}
 
+	c.initServices()
+
+	// apply any options
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// initServices binds every service to c.
+func (c *Client) initServices() {
 	c.Product = &ProductServiceOp{client: c}
 	c.CustomCollection = &CustomCollectionServiceOp{client: c}
 	c.SmartCollection = &SmartCollectionServiceOp{client: c}
@@ -289,17 +362,38 @@ func NewClient(app App, shopName, token string, opts ...Option) *Client {
 	c.DiscountCode = &DiscountCodeServiceOp{client: c}
 	c.PriceRule = &PriceRuleServiceOp{client: c}
 	c.InventoryItem = &InventoryItemServiceOp{client: c}
+	c.InventoryLevel = &InventoryLevelServiceOp{client: c}
 	c.ShippingZone = &ShippingZoneServiceOp{client: c}
 	c.ProductListing = &ProductListingServiceOp{client: c}
 	c.AbandonedCheckouts = &AbandonedCheckoutsServiceOp{client: c}
 	c.FulfillmentOrder = &FulfillmentOrderServiceOp{client: c}
+	c.GraphQL = &GraphQLServiceOp{client: c}
+	c.BulkOperation = &BulkOperationServiceOp{client: c}
+	c.AccessScope = &AccessScopeServiceOp{client: c}
+}
 
-	// apply any options
-	for _, opt := range opts {
-		opt(c)
+// WithContext returns a shallow copy of c whose requests, including those
+// made through its services, are bound to ctx.
+//
+//	products, err := client.WithContext(ctx).Product.List(nil)
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
 	}
+	c2 := new(Client)
+	*c2 = *c
+	c2.ctx = ctx
+	c2.initServices()
+	return c2
+}
 
-	return c
+// context returns the context requests should be bound to, defaulting to
+// context.Background.
+func (c *Client) context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
 }
 
 // Do sends an API request and populates the given interface with the parsed
@@ -316,83 +410,128 @@ func (c *Client) Do(req *http.Request, v interface{}) error {
 
 // doGetHeaders executes a request, decoding the response into `v` and also returns any response headers.
 func (c *Client) doGetHeaders(req *http.Request, v interface{}) (http.Header, error) {
+	meta, err := c.doResponse(req, v)
+	if err != nil {
+		return nil, err
+	}
+	return meta.Header, nil
+}
+
+// doResponse executes a request, decoding the response into `v`, and returns
+// the metadata of the response.
+func (c *Client) doResponse(req *http.Request, v interface{}) (*Response, error) {
 	var resp *http.Response
 	var err error
-	retries := c.retries
-	c.attempts = 0
 	c.logRequest(req)
 
-	for {
-		c.attempts++
-		resp, err = c.Client.Do(req)
-		c.logResponse(resp)
-		if err != nil {
-			return nil, err //http client errors, not api responses
+	// metadata of this call, kept out of the client so concurrent calls do
+	// not race
+	meta := new(Response)
+	defer func() {
+		if meta.Deprecated() {
+			c.reportDeprecation(req, meta)
 		}
-
-		respErr := CheckResponseError(resp)
-		if respErr == nil {
-			break // no errors, break out of the retry loop
+		if c.response != nil {
+			*c.response = *meta
 		}
+	}()
 
-		// retry scenario, close resp and any continue will retry
-		resp.Body.Close()
+	if !c.tokenExpiry.IsZero() && !time.Now().Before(c.tokenExpiry) {
+		return meta, ErrTokenExpired
+	}
 
-		if retries <= 1 {
-			return nil, respErr
+	policy := c.policy()
+	start := time.Now()
+
+	for {
+		if err := req.Context().Err(); err != nil {
+			return meta, err
 		}
 
-		if rateLimitErr, isRetryErr := respErr.(RateLimitError); isRetryErr {
-			// back off and retry
+		if c.rateLimiter != nil {
+			if err := c.rateLimiter.Wait(req.Context()); err != nil {
+				return meta, err
+			}
+		}
 
-			wait := time.Duration(rateLimitErr.RetryAfter) * time.Second
-			c.log.Debugf("rate limited waiting %s", wait.String())
-			time.Sleep(wait)
-			retries--
-			continue
+		// the body was consumed by the previous attempt
+		if meta.Attempts > 0 && req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return meta, err
+			}
 		}
 
-		var doRetry bool
-		switch resp.StatusCode {
-		case http.StatusServiceUnavailable:
-			c.log.Debugf("service unavailable, retrying")
-			doRetry = true
-			retries--
+		meta.Attempts++
+		resp, err = c.Client.Do(req)
+		c.logResponse(resp)
+
+		var respErr error
+		if err != nil {
+			respErr = err //http client errors, not api responses
+		} else {
+			meta.setResponse(resp)
+
+			if c.rateLimiter != nil {
+				c.rateLimiter.Update(meta.RateLimits)
+			}
+
+			// transports are not required to set it, errors report method and path
+			if resp.Request == nil {
+				resp.Request = req
+			}
+
+			respErr = CheckResponseError(resp)
+			if respErr == nil {
+				break // no errors, break out of the retry loop
+			}
+
+			// retry scenario, close resp and any continue will retry
+			resp.Body.Close()
 		}
 
-		if doRetry {
-			continue
+		wait, retry := policy.Retry(req, respErr, meta.Attempts, time.Since(start))
+		if !retry {
+			if c.uninstallHook != nil && errors.Is(respErr, ErrTokenRevoked) {
+				c.reportUninstall()
+			}
+			// no retry attempts, just return the err
+			return meta, respErr
 		}
 
-		// no retry attempts, just return the err
-		return nil, respErr
+		c.log.Debugf("request failed with %v, retrying in %s", respErr, wait.String())
+		if err := sleepContext(req.Context(), wait); err != nil {
+			return meta, err
+		}
 	}
 
 	c.logResponse(resp)
 	defer resp.Body.Close()
 
-	if c.apiVersion == defaultApiVersion && resp.Header.Get("X-Shopify-API-Version") != "" {
-		// if using stable on first request set the api version
-		c.apiVersion = resp.Header.Get("X-Shopify-API-Version")
-		c.log.Infof("api version not set, now using %s", c.apiVersion)
-	}
+	c.updateState(resp.Header)
 
 	if v != nil {
 		decoder := json.NewDecoder(resp.Body)
 		err := decoder.Decode(&v)
 		if err != nil {
-			return nil, err
+			return meta, err
 		}
 	}
 
-	if s := strings.Split(resp.Header.Get("X-Shopify-Shop-Api-Call-Limit"), "/"); len(s) == 2 {
-		c.RateLimits.RequestCount, _ = strconv.Atoi(s[0])
-		c.RateLimits.BucketSize, _ = strconv.Atoi(s[1])
-	}
+	return meta, nil
+}
 
-	c.RateLimits.RetryAfterSeconds, _ = strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+// sleepContext pauses for d or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
 
-	return resp.Header, nil
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 func (c *Client) logRequest(req *http.Request) {
@@ -424,14 +563,29 @@ func (c *Client) logBody(body *io.ReadCloser, format string) {
 	*body = ioutil.NopCloser(bytes.NewBuffer(b))
 }
 
-func wrapSpecificError(r *http.Response, err ResponseError) error {
+func wrapSpecificError(r *http.Response, err ResponseError, fields map[string][]string) error {
 	// see https://www.shopify.dev/concepts/about-apis/response-codes
-	if err.Status == http.StatusTooManyRequests {
+	switch {
+	case err.Status == http.StatusTooManyRequests:
 		f, _ := strconv.ParseFloat(r.Header.Get("Retry-After"), 64)
 		return RateLimitError{
 			ResponseError: err,
 			RetryAfter:    int(f),
 		}
+	case err.Status == http.StatusUnauthorized:
+		return InvalidTokenError{err}
+	case err.Status == http.StatusPaymentRequired:
+		return PaymentRequiredError{err}
+	case err.Status == http.StatusForbidden:
+		return ForbiddenError{err}
+	case err.Status == http.StatusNotFound:
+		return NotFoundError{err}
+	case err.Status == http.StatusUnprocessableEntity:
+		return ValidationError{ResponseError: err, Fields: fields}
+	case err.Status == http.StatusLocked:
+		return ShopLockedError{err}
+	case err.Status >= http.StatusInternalServerError:
+		return ServerError{err}
 	}
 
 	// if err.Status == http.StatusSeeOther {
@@ -463,30 +617,46 @@ func CheckResponseError(r *http.Response) error {
 		return err
 	}
 
+	// Create the response error from the Shopify error.
+	responseError := ResponseError{
+		Status:    r.StatusCode,
+		RequestID: r.Header.Get("X-Request-Id"),
+	}
+	if r.Request != nil {
+		responseError.Method = r.Request.Method
+		responseError.Path = r.Request.URL.Path
+	}
+
 	// empty body, this probably means shopify returned an error with no body
 	// we'll handle that error in wrapSpecificError()
 	if len(bodyBytes) > 0 {
 		err := json.Unmarshal(bodyBytes, &shopifyError)
 		if err != nil {
+			// gateways answer 5xx with html pages, still a ServerError
+			if r.StatusCode >= http.StatusInternalServerError {
+				responseError.Message = http.StatusText(r.StatusCode)
+				return wrapSpecificError(r, responseError, nil)
+			}
 			return ResponseDecodingError{
-				Body:    bodyBytes,
-				Message: err.Error(),
-				Status:  r.StatusCode,
+				Body:      bodyBytes,
+				Message:   err.Error(),
+				Status:    r.StatusCode,
+				RequestID: responseError.RequestID,
+				Method:    responseError.Method,
+				Path:      responseError.Path,
 			}
 		}
 	}
-
-	// Create the response error from the Shopify error.
-	responseError := ResponseError{
-		Status:  r.StatusCode,
-		Message: shopifyError.Error,
-	}
+	responseError.Message = shopifyError.Error
 
 	// If the errors field is not filled out, we can return here.
 	if shopifyError.Errors == nil {
-		return wrapSpecificError(r, responseError)
+		return wrapSpecificError(r, responseError, nil)
 	}
 
+	// fields keeps the messages of each field for validation errors
+	var fields map[string][]string
+
 	// Shopify errors usually have the form:
 	// {
 	//   "errors": {
@@ -512,34 +682,63 @@ func CheckResponseError(r *http.Response) error {
 		}
 		responseError.Message = strings.Join(responseError.Errors, ", ")
 	case reflect.Map:
-		// A map, parse each error for each key in the map.
+		// A map, collect the messages of each field. Nested objects and arrays
+		// of objects are walked, their fields get dotted paths such as
+		// "line_items.0.quantity".
 		// json always serializes into map[string]interface{} for objects
-		for k, v := range shopifyError.Errors.(map[string]interface{}) {
-			switch reflect.TypeOf(v).Kind() {
-			// Check to make sure the interface is a slice
-			// json always serializes JSON arrays into []interface{}
-			case reflect.Slice:
-				for _, elem := range v.([]interface{}) {
-					// If the primary message of the response error is not set, use
-					// any message.
-					if responseError.Message == "" {
-						responseError.Message = fmt.Sprintf("%v: %v", k, elem)
-					}
-					topicAndElem := fmt.Sprintf("%v: %v", k, elem)
-					responseError.Errors = append(responseError.Errors, topicAndElem)
-				}
-			case reflect.String:
-				elem := v.(string)
+		fields = map[string][]string{}
+		collectErrorFields("", shopifyError.Errors, fields)
+
+		paths := make([]string, 0, len(fields))
+		for path := range fields {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			for _, elem := range fields[path] {
+				topicAndElem := fmt.Sprintf("%v: %v", path, elem)
+				// If the primary message of the response error is not set, use
+				// the first message.
 				if responseError.Message == "" {
-					responseError.Message = fmt.Sprintf("%v: %v", k, elem)
+					responseError.Message = topicAndElem
 				}
-				topicAndElem := fmt.Sprintf("%v: %v", k, elem)
 				responseError.Errors = append(responseError.Errors, topicAndElem)
 			}
 		}
 	}
 
-	return wrapSpecificError(r, responseError)
+	return wrapSpecificError(r, responseError, fields)
+}
+
+// collectErrorFields adds the messages found in v to fields, keyed on the
+// dotted path of the field they belong to.
+func collectErrorFields(path string, v interface{}, fields map[string][]string) {
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for k, elem := range v {
+			collectErrorFields(joinErrorPath(path, k), elem, fields)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			switch elem.(type) {
+			case map[string]interface{}, []interface{}:
+				collectErrorFields(joinErrorPath(path, strconv.Itoa(i)), elem, fields)
+			default:
+				collectErrorFields(path, elem, fields)
+			}
+		}
+	default:
+		fields[path] = append(fields[path], fmt.Sprint(v))
+	}
+}
+
+func joinErrorPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
 }
 
 // General list options that can be used for most collections of entities.
@@ -589,7 +788,12 @@ func (c *Client) Count(path string, options interface{}) (int, error) {
 // parameters like created_at_min
 // Any data returned from Shopify will be marshalled into resource argument.
 func (c *Client) CreateAndDo(method, relPath string, data, options, resource interface{}) error {
-	_, err := c.createAndDoGetHeaders(method, relPath, data, options, resource)
+	return c.CreateAndDoWithContext(c.context(), method, relPath, data, options, resource)
+}
+
+// CreateAndDoWithContext is like CreateAndDo but the request is bound to ctx.
+func (c *Client) CreateAndDoWithContext(ctx context.Context, method, relPath string, data, options, resource interface{}) error {
+	_, err := c.createAndDoGetHeadersWithContext(ctx, method, relPath, data, options, resource)
 	if err != nil {
 		return err
 	}
@@ -598,13 +802,17 @@ func (c *Client) CreateAndDo(method, relPath string, data, options, resource int
 
 // createAndDoGetHeaders creates an executes a request while returning the response headers.
 func (c *Client) createAndDoGetHeaders(method, relPath string, data, options, resource interface{}) (http.Header, error) {
+	return c.createAndDoGetHeadersWithContext(c.context(), method, relPath, data, options, resource)
+}
+
+func (c *Client) createAndDoGetHeadersWithContext(ctx context.Context, method, relPath string, data, options, resource interface{}) (http.Header, error) {
 	if strings.HasPrefix(relPath, "/") {
 		// make sure it's a relative path
 		relPath = strings.TrimLeft(relPath, "/")
 	}
 
 	relPath = path.Join(c.pathPrefix, relPath)
-	req, err := c.NewRequest(method, relPath, data, options)
+	req, err := c.NewRequestWithContext(ctx, method, relPath, data, options)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package shopify
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -272,12 +273,12 @@ func TestDo(t *testing.T) {
 		{
 			"foo/2",
 			httpmock.NewStringResponder(404, `{"error": "does not exist"}`),
-			ResponseError{Status: 404, Message: "does not exist"},
+			NotFoundError{ResponseError{Status: 404, Message: "does not exist", Method: "GET", Path: "/foo/2"}},
 		},
 		{
 			"foo/3",
 			httpmock.NewStringResponder(400, `{"errors": {"title": ["wrong"]}}`),
-			ResponseError{Status: 400, Message: "title: wrong", Errors: []string{"title: wrong"}},
+			ResponseError{Status: 400, Message: "title: wrong", Errors: []string{"title: wrong"}, Method: "GET", Path: "/foo/3"},
 		},
 		{
 			"foo/4",
@@ -301,6 +302,8 @@ func TestDo(t *testing.T) {
 				ResponseError: ResponseError{
 					Status:  429,
 					Message: "Exceeded 2 calls per second for api client. Reduce request rates to resume uninterrupted service.",
+					Method:  "GET",
+					Path:    "/foo/6",
 				},
 			},
 		},
@@ -310,15 +313,29 @@ func TestDo(t *testing.T) {
 			ResponseError{
 				Status:  406,
 				Message: "Not Acceptable",
+				Method:  "GET",
+				Path:    "/foo/7",
 			},
 		},
 		{
 			"foo/8",
 			httpmock.NewStringResponder(500, "<html></html>"),
+			ServerError{ResponseError{
+				Status:  500,
+				Message: "Internal Server Error",
+				Method:  "GET",
+				Path:    "/foo/8",
+			}},
+		},
+		{
+			"foo/9",
+			httpmock.NewStringResponder(404, "<html></html>"),
 			ResponseDecodingError{
 				Body:    []byte("<html></html>"),
 				Message: "invalid character '<' looking for beginning of value",
-				Status:  500,
+				Status:  404,
+				Method:  "GET",
+				Path:    "/foo/9",
 			},
 		},
 	}
@@ -398,6 +415,8 @@ func TestRetry(t *testing.T) {
 				ResponseError: ResponseError{
 					Status:  429,
 					Message: "Exceeded 2 calls per second for api client. Reduce request rates to resume uninterrupted service.",
+					Method:  "GET",
+					Path:    "/foo/3",
 				},
 			},
 			responder: func(req *http.Request) (*http.Response, error) {
@@ -422,9 +441,11 @@ func TestRetry(t *testing.T) {
 		{ // all retries 503
 			relPath: "foo/5",
 			retries: maxRetries,
-			expected: ResponseError{
+			expected: ServerError{ResponseError{
 				Status: http.StatusServiceUnavailable,
-			},
+				Method: "GET",
+				Path:   "/foo/5",
+			}},
 			responder: func(req *http.Request) (*http.Response, error) {
 				return httpmock.NewStringResponse(http.StatusServiceUnavailable, ""), nil
 			},
@@ -440,10 +461,11 @@ func TestRetry(t *testing.T) {
 			t.Error("error creating request: ", err)
 		}
 
-		err = client.Do(req, body)
+		var resp Response
+		err = client.WithResponse(&resp).Do(req, body)
 
-		if client.attempts != c.retries {
-			t.Errorf("Do(): attempts do not match retries %#v, actual %#v", client.attempts, c.retries)
+		if resp.Attempts != c.retries {
+			t.Errorf("Do(): attempts do not match retries %#v, actual %#v", resp.Attempts, c.retries)
 		}
 
 		if err != nil {
@@ -486,10 +508,10 @@ func TestClientDoAutoApiVersion(t *testing.T) {
 		t.Errorf("TestClientDoApiVersion(): errored %s", err)
 	}
 
-	if expected != testClient.apiVersion {
+	if expected != testClient.APIVersion() {
 		t.Errorf(
 			"TestClientDoApiVersion(): client unable to get API Version from X-Shopify-API-Version: expected %s received %s",
-			expected, testClient.apiVersion)
+			expected, testClient.APIVersion())
 	}
 }
 
@@ -742,6 +764,10 @@ func TestCheckResponseError(t *testing.T) {
 			httpmock.NewStringResponse(400, `{"errors": { "collection_id": "collection_id is wrong" }}`),
 			ResponseError{Status: 400, Message: "collection_id: collection_id is wrong", Errors: []string{"collection_id: collection_id is wrong"}},
 		},
+		{
+			httpmock.NewStringResponse(400, `{"errors": { "line_items": { "0": { "quantity": ["is wrong"] } } }}`),
+			ResponseError{Status: 400, Message: "line_items.0.quantity: is wrong", Errors: []string{"line_items.0.quantity: is wrong"}},
+		},
 		{
 			httpmock.NewStringResponse(400, `{error:bad request}`),
 			errors.New("invalid character 'e' looking for beginning of object key string"),
@@ -892,9 +918,84 @@ func TestDoRateLimit(t *testing.T) {
 				if !reflect.DeepEqual(err, c.expected) {
 					t.Errorf("Do(): expected error %#v, actual %#v", c.expected, err)
 				}
-			} else if err == nil && !reflect.DeepEqual(client.RateLimits, c.expected) {
-				t.Errorf("%s: expected %#v, actual %#v", c.description, c.expected, client.RateLimits)
+			} else if err == nil && !reflect.DeepEqual(client.LastRateLimits(), c.expected) {
+				t.Errorf("%s: expected %#v, actual %#v", c.description, c.expected, client.LastRateLimits())
 			}
 		})
 	}
 }
+
+func TestWithContext(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/products/1.json", client.pathPrefix),
+		httpmock.NewStringResponder(200, `{"product":{"id":1}}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctxClient := client.WithContext(ctx)
+
+	product, err := ctxClient.Product.Get(1, nil)
+	if err != nil {
+		t.Fatalf("Product.Get returned error: %v", err)
+	}
+	if product.ID != 1 {
+		t.Errorf("Product.Get returned id %d, expected 1", product.ID)
+	}
+
+	cancel()
+	_, err = ctxClient.Product.Get(1, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Product.Get with cancelled context returned %v, expected %v", err, context.Canceled)
+	}
+
+	// the original client is not bound to the cancelled context
+	if _, err = client.Product.Get(1, nil); err != nil {
+		t.Errorf("Product.Get returned error: %v", err)
+	}
+}
+
+func TestDoRateLimitContextCancelled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", "https://fooshop.myshopify.com/foo/1",
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusTooManyRequests, `{"errors":"Exceeded 2 calls per second for api client."}`)
+			resp.Header.Add("Retry-After", "60.0")
+			return resp, nil
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := client.NewRequestWithContext(ctx, "GET", "foo/1", nil, nil)
+	if err != nil {
+		t.Fatalf("NewRequestWithContext returned error: %v", err)
+	}
+
+	start := time.Now()
+	err = client.Do(req, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do(): expected error %v, actual %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Do(): rate limit backoff was not aborted, took %s", elapsed)
+	}
+}
+
+func TestCreateAndDoWithContext(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/foo/1", client.pathPrefix),
+		httpmock.NewStringResponder(200, `{"foo": "bar"}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := client.CreateAndDoWithContext(ctx, "GET", "foo/1", nil, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateAndDoWithContext(): expected error %v, actual %v", context.Canceled, err)
+	}
+}
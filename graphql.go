@@ -0,0 +1,193 @@
+package shopify
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"time"
+)
+
+const graphQLPath = "graphql.json"
+
+// GraphQLService is an interface for interfacing with the GraphQL Admin API of
+// Shopify. Requests use the client's authentication, api version, logger and
+// retries.
+// See: https://shopify.dev/api/admin-graphql
+type GraphQLService interface {
+	Query(query string, variables, resp interface{}) (*GraphQLCost, error)
+}
+
+// GraphQLServiceOp handles communication with the GraphQL endpoint of the
+// Shopify API.
+type GraphQLServiceOp struct {
+	client *Client
+}
+
+// GraphQLCost is the cost of a query as reported in the extensions of a
+// GraphQL response.
+// See: https://shopify.dev/api/usage/rate-limits#graphql-admin-api-rate-limits
+type GraphQLCost struct {
+	RequestedQueryCost int                   `json:"requestedQueryCost"`
+	ActualQueryCost    *int                  `json:"actualQueryCost"`
+	ThrottleStatus     GraphQLThrottleStatus `json:"throttleStatus"`
+}
+
+// GraphQLThrottleStatus is the state of the shop's GraphQL leaky bucket.
+type GraphQLThrottleStatus struct {
+	MaximumAvailable   float64 `json:"maximumAvailable"`
+	CurrentlyAvailable float64 `json:"currentlyAvailable"`
+	RestoreRate        float64 `json:"restoreRate"`
+}
+
+// GraphQLExtensions represents the extensions of a GraphQL response.
+type GraphQLExtensions struct {
+	Cost *GraphQLCost `json:"cost"`
+}
+
+// GraphQLErrorLocation is the position in the query a GraphQLError refers to.
+type GraphQLErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is a single entry of the errors of a GraphQL response.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Locations  []GraphQLErrorLocation `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Code returns the error code found in the extensions of the error, e.g.
+// "THROTTLED".
+func (e GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// GraphQLErrors is returned by GraphQLService.Query when the response
+// contains errors. The data that could be resolved is still decoded.
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Throttled reports whether the query was rejected because the shop's
+// GraphQL bucket did not hold enough points.
+func (e GraphQLErrors) Throttled() bool {
+	for _, err := range e {
+		if err.Code() == "THROTTLED" {
+			return true
+		}
+	}
+	return false
+}
+
+type graphQLRequest struct {
+	Query     string      `json:"query"`
+	Variables interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data       interface{}        `json:"data"`
+	Errors     GraphQLErrors      `json:"errors"`
+	Extensions *GraphQLExtensions `json:"extensions"`
+}
+
+// Query sends a GraphQL query or mutation with the given variables and
+// decodes the data of the response into resp. It returns the cost of the
+// query, if reported, and GraphQLErrors when the response contains errors.
+// Throttled queries are retried as the RetryPolicy of the client allows,
+// waiting at least until the bucket restored enough points.
+func (s *GraphQLServiceOp) Query(query string, variables, resp interface{}) (*GraphQLCost, error) {
+	data := graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	}
+
+	// without a version the endpoint lives at admin/api/graphql.json
+	relPath := graphQLPath
+	if s.client.pathPrefix == defaultApiPathPrefix {
+		relPath = "api/" + graphQLPath
+	}
+	relPath = path.Join(s.client.pathPrefix, relPath)
+
+	policy := s.client.policy()
+	start := time.Now()
+	attempts := 0
+	for {
+		attempts++
+		req, err := s.client.NewRequestWithContext(s.client.context(), "POST", relPath, data, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		result := graphQLResponse{Data: resp}
+		if err := s.client.Do(req, &result); err != nil {
+			return nil, err
+		}
+
+		var cost *GraphQLCost
+		if result.Extensions != nil {
+			cost = result.Extensions.Cost
+		}
+
+		if len(result.Errors) == 0 {
+			return cost, nil
+		}
+
+		if !result.Errors.Throttled() || cost == nil {
+			return cost, result.Errors
+		}
+
+		wait, retry := policy.Retry(req, result.Errors, attempts, time.Since(start))
+		if !retry {
+			return cost, result.Errors
+		}
+		if restore := cost.restoreWait(); restore > wait {
+			wait = restore
+		}
+		s.client.log.Debugf("graphql throttled waiting %s", wait.String())
+		if err := sleepContext(s.client.context(), wait); err != nil {
+			return cost, err
+		}
+	}
+}
+
+// restoreWait returns how long it takes until the bucket holds enough points
+// for the requested query cost.
+func (c GraphQLCost) restoreWait() time.Duration {
+	missing := float64(c.RequestedQueryCost) - c.ThrottleStatus.CurrentlyAvailable
+	if missing <= 0 || c.ThrottleStatus.RestoreRate <= 0 {
+		return time.Second
+	}
+	return time.Duration(math.Ceil(missing/c.ThrottleStatus.RestoreRate*1000)) * time.Millisecond
+}
+
+// GraphQLUserError is a user error returned by a GraphQL mutation, e.g. for
+// invalid input.
+type GraphQLUserError struct {
+	Field   []string `json:"field"`
+	Message string   `json:"message"`
+}
+
+// GraphQLUserErrors is returned when a mutation reports user errors.
+type GraphQLUserErrors []GraphQLUserError
+
+func (e GraphQLUserErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		if len(err.Field) > 0 {
+			messages = append(messages, fmt.Sprintf("%s: %s", strings.Join(err.Field, "."), err.Message))
+		} else {
+			messages = append(messages, err.Message)
+		}
+	}
+	return strings.Join(messages, ", ")
+}
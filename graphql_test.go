@@ -0,0 +1,170 @@
+package shopify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestGraphQLQuery(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/graphql.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			sent := map[string]interface{}{}
+			json.Unmarshal(body, &sent)
+			if sent["query"] != "query($id: ID!) { product(id: $id) { title } }" {
+				t.Errorf("GraphQL.Query sent query %v", sent["query"])
+			}
+			if req.Header.Get("X-Shopify-Access-Token") != "abcd" {
+				t.Errorf("GraphQL.Query did not send the access token")
+			}
+			return httpmock.NewStringResponse(200, `{
+				"data": {"product": {"title": "Burton Custom Freestyle 151"}},
+				"extensions": {"cost": {"requestedQueryCost": 1, "actualQueryCost": 1, "throttleStatus": {"maximumAvailable": 1000.0, "currentlyAvailable": 999, "restoreRate": 50.0}}}
+			}`), nil
+		})
+
+	resp := struct {
+		Product struct {
+			Title string `json:"title"`
+		} `json:"product"`
+	}{}
+	variables := map[string]interface{}{"id": "gid://shopify/Product/1"}
+	cost, err := client.GraphQL.Query("query($id: ID!) { product(id: $id) { title } }", variables, &resp)
+	if err != nil {
+		t.Fatalf("GraphQL.Query returned error: %v", err)
+	}
+
+	if resp.Product.Title != "Burton Custom Freestyle 151" {
+		t.Errorf("GraphQL.Query returned title %q", resp.Product.Title)
+	}
+
+	actual := 1
+	expected := &GraphQLCost{
+		RequestedQueryCost: 1,
+		ActualQueryCost:    &actual,
+		ThrottleStatus: GraphQLThrottleStatus{
+			MaximumAvailable:   1000,
+			CurrentlyAvailable: 999,
+			RestoreRate:        50,
+		},
+	}
+	if !reflect.DeepEqual(cost, expected) {
+		t.Errorf("GraphQL.Query returned cost %+v, expected %+v", cost, expected)
+	}
+}
+
+func TestGraphQLQueryUnversioned(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient := NewClient(app, "fooshop", "abcd")
+	httpmock.ActivateNonDefault(testClient.Client)
+	httpmock.RegisterResponder("POST", "https://fooshop.myshopify.com/admin/api/graphql.json",
+		httpmock.NewStringResponder(200, `{"data": {}}`))
+
+	if _, err := testClient.GraphQL.Query("{ shop { name } }", nil, nil); err != nil {
+		t.Errorf("GraphQL.Query returned error: %v", err)
+	}
+}
+
+func TestGraphQLQueryErrors(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/graphql.json", client.pathPrefix),
+		httpmock.NewStringResponder(200, `{
+			"data": null,
+			"errors": [{"message": "Field 'foo' doesn't exist on type 'Shop'", "locations": [{"line": 1, "column": 9}], "path": ["query", "shop", "foo"], "extensions": {"code": "undefinedField"}}]
+		}`))
+
+	_, err := client.GraphQL.Query("{ shop { foo } }", nil, nil)
+	errs, ok := err.(GraphQLErrors)
+	if !ok {
+		t.Fatalf("GraphQL.Query returned error %#v, expected GraphQLErrors", err)
+	}
+
+	if len(errs) != 1 || errs[0].Code() != "undefinedField" || errs[0].Locations[0].Column != 9 {
+		t.Errorf("GraphQL.Query returned errors %+v", errs)
+	}
+	if errs.Error() != "Field 'foo' doesn't exist on type 'Shop'" {
+		t.Errorf("GraphQLErrors.Error() = %q", errs.Error())
+	}
+	if errs.Throttled() {
+		t.Errorf("GraphQLErrors.Throttled() = true, expected false")
+	}
+}
+
+func TestGraphQLQueryThrottled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/graphql.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return httpmock.NewStringResponse(200, `{
+					"errors": [{"message": "Throttled", "extensions": {"code": "THROTTLED"}}],
+					"extensions": {"cost": {"requestedQueryCost": 10, "actualQueryCost": null, "throttleStatus": {"maximumAvailable": 1000.0, "currentlyAvailable": 9, "restoreRate": 1000.0}}}
+				}`), nil
+			}
+			return httpmock.NewStringResponse(200, `{"data": {"shop": {"name": "foo"}}}`), nil
+		})
+
+	resp := struct {
+		Shop struct {
+			Name string `json:"name"`
+		} `json:"shop"`
+	}{}
+	if _, err := client.GraphQL.Query("{ shop { name } }", nil, &resp); err != nil {
+		t.Fatalf("GraphQL.Query returned error: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("GraphQL.Query sent %d requests, expected 2", calls)
+	}
+	if resp.Shop.Name != "foo" {
+		t.Errorf("GraphQL.Query returned name %q, expected foo", resp.Shop.Name)
+	}
+}
+
+func TestGraphQLQueryThrottledRetryPolicy(t *testing.T) {
+	setup()
+	defer teardown()
+
+	policy := NewBackoffRetryPolicy(2)
+	policy.MinBackoff = time.Millisecond
+	client := NewClient(app, "fooshop", "abcd", WithVersion(testApiVersion), WithRetryPolicy(policy))
+	httpmock.ActivateNonDefault(client.Client)
+
+	calls := 0
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/graphql.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			calls++
+			return httpmock.NewStringResponse(200, `{
+				"errors": [{"message": "Throttled", "extensions": {"code": "THROTTLED"}}],
+				"extensions": {"cost": {"requestedQueryCost": 10, "actualQueryCost": null, "throttleStatus": {"maximumAvailable": 1000.0, "currentlyAvailable": 9, "restoreRate": 1000.0}}}
+			}`), nil
+		})
+
+	_, err := client.GraphQL.Query("{ shop { name } }", nil, nil)
+	var errs GraphQLErrors
+	if !errors.As(err, &errs) || !errs.Throttled() {
+		t.Fatalf("GraphQL.Query returned error %v, expected throttled GraphQLErrors", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("GraphQL.Query sent %d requests, expected 2", calls)
+	}
+}
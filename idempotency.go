@@ -0,0 +1,102 @@
+package shopify
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultIdempotencyWindow is how long a webhook delivery is remembered
+	// by default. Shopify retries failed deliveries for up to 48 hours.
+	DefaultIdempotencyWindow = 48 * time.Hour
+	// DefaultIdempotencyCapacity is the number of deliveries remembered by
+	// the default in-memory store.
+	DefaultIdempotencyCapacity = 10000
+)
+
+// IdempotencyStore records which webhook deliveries were processed, keyed on
+// the X-Shopify-Webhook-Id header, so redeliveries can be skipped. Stores
+// shared between processes, e.g. backed by Redis, make handlers run at most
+// once per delivery across all of them.
+type IdempotencyStore interface {
+	// Claim records key for ttl and reports whether it was not recorded
+	// already, i.e. whether the caller should process the delivery.
+	Claim(key string, ttl time.Duration) (bool, error)
+	// Release forgets key, so a redelivery is processed again. It is called
+	// when processing a claimed delivery failed.
+	Release(key string) error
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore. It remembers at
+// most capacity keys, evicting the least recently used ones first. It is safe
+// for concurrent use.
+type MemoryIdempotencyStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	// most recently used entries are at the front
+	lru *list.List
+}
+
+type idempotencyEntry struct {
+	key     string
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore returns a MemoryIdempotencyStore remembering at
+// most capacity keys.
+func NewMemoryIdempotencyStore(capacity int) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		capacity: capacity,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+	}
+}
+
+// Claim records key for ttl and reports whether it was not recorded already.
+func (s *MemoryIdempotencyStore) Claim(key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if elem, ok := s.entries[key]; ok {
+		entry := elem.Value.(*idempotencyEntry)
+		if now.Before(entry.expires) {
+			s.lru.MoveToFront(elem)
+			return false, nil
+		}
+		s.remove(elem)
+	}
+
+	s.entries[key] = s.lru.PushFront(&idempotencyEntry{key: key, expires: now.Add(ttl)})
+	for s.capacity > 0 && s.lru.Len() > s.capacity {
+		s.remove(s.lru.Back())
+	}
+	return true, nil
+}
+
+// Release forgets key.
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		s.remove(elem)
+	}
+	return nil
+}
+
+// Len returns the number of keys remembered, including expired ones not yet
+// evicted.
+func (s *MemoryIdempotencyStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// remove drops elem from the store, s.mu must be held.
+func (s *MemoryIdempotencyStore) remove(elem *list.Element) {
+	s.lru.Remove(elem)
+	delete(s.entries, elem.Value.(*idempotencyEntry).key)
+}
@@ -0,0 +1,69 @@
+package shopify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotencyStoreClaim(t *testing.T) {
+	store := NewMemoryIdempotencyStore(10)
+
+	cases := []struct {
+		key      string
+		expected bool
+	}{
+		{"a", true},
+		{"a", false},
+		{"b", true},
+		{"a", false},
+	}
+
+	for _, c := range cases {
+		claimed, err := store.Claim(c.key, time.Hour)
+		if err != nil {
+			t.Fatalf("MemoryIdempotencyStore.Claim returned error: %v", err)
+		}
+		if claimed != c.expected {
+			t.Errorf("MemoryIdempotencyStore.Claim(%q) = %v, expected %v", c.key, claimed, c.expected)
+		}
+	}
+
+	store.Release("a")
+	if claimed, _ := store.Claim("a", time.Hour); !claimed {
+		t.Errorf("MemoryIdempotencyStore.Claim after Release = false, expected true")
+	}
+}
+
+func TestMemoryIdempotencyStoreExpiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore(10)
+
+	store.Claim("a", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if claimed, _ := store.Claim("a", time.Hour); !claimed {
+		t.Errorf("MemoryIdempotencyStore.Claim of expired key = false, expected true")
+	}
+	if store.Len() != 1 {
+		t.Errorf("MemoryIdempotencyStore.Len() = %d, expected 1", store.Len())
+	}
+}
+
+func TestMemoryIdempotencyStoreEviction(t *testing.T) {
+	store := NewMemoryIdempotencyStore(2)
+
+	store.Claim("a", time.Hour)
+	store.Claim("b", time.Hour)
+	// touching a makes b the least recently used key
+	store.Claim("a", time.Hour)
+	store.Claim("c", time.Hour)
+
+	if store.Len() != 2 {
+		t.Errorf("MemoryIdempotencyStore.Len() = %d, expected 2", store.Len())
+	}
+	if claimed, _ := store.Claim("a", time.Hour); claimed {
+		t.Errorf("MemoryIdempotencyStore evicted the recently used key a")
+	}
+	if claimed, _ := store.Claim("b", time.Hour); !claimed {
+		t.Errorf("MemoryIdempotencyStore did not evict the least recently used key b")
+	}
+}
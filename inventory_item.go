@@ -14,6 +14,8 @@ const inventoryItemsBasePath = "inventory_items"
 // See https://help.shopify.com/en/api/reference/inventory/inventoryitem
 type InventoryItemService interface {
 	List(interface{}) ([]InventoryItem, error)
+	ListWithPagination(interface{}) ([]InventoryItem, *Pagination, error)
+	ListAll(interface{}, func([]InventoryItem) error) error
 	Get(int64, interface{}) (*InventoryItem, error)
 	Update(InventoryItem) (*InventoryItem, error)
 }
@@ -52,6 +54,30 @@ func (s *InventoryItemServiceOp) List(options interface{}) ([]InventoryItem, err
 	return resource.InventoryItems, err
 }
 
+// ListWithPagination lists inventory items and return pagination to retrieve next/previous results.
+func (s *InventoryItemServiceOp) ListWithPagination(options interface{}) ([]InventoryItem, *Pagination, error) {
+	path := fmt.Sprintf("%s.json", inventoryItemsBasePath)
+	resource := new(InventoryItemsResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.InventoryItems, pagination, nil
+}
+
+// ListAll walks every page of inventory items, calling fn with each page. See Client.ListAll.
+func (s *InventoryItemServiceOp) ListAll(options interface{}, fn func([]InventoryItem) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		items, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(items)
+	})
+}
+
 // Get a inventory item
 func (s *InventoryItemServiceOp) Get(id int64, options interface{}) (*InventoryItem, error) {
 	path := fmt.Sprintf("%s/%d.json", inventoryItemsBasePath, id)
@@ -0,0 +1,150 @@
+package shopify
+
+import (
+	"fmt"
+	"time"
+)
+
+const inventoryLevelsBasePath = "inventory_levels"
+
+// InventoryLevelService is an interface for interacting with the
+// inventory levels endpoints of the Shopify API
+// See https://shopify.dev/api/admin-rest/latest/resources/inventorylevel
+type InventoryLevelService interface {
+	List(interface{}) ([]InventoryLevel, error)
+	ListWithPagination(interface{}) ([]InventoryLevel, *Pagination, error)
+	ListAll(interface{}, func([]InventoryLevel) error) error
+	Adjust(InventoryLevelAdjustOptions) (*InventoryLevel, error)
+	Set(InventoryLevelSetOptions) (*InventoryLevel, error)
+	Connect(InventoryLevelConnectOptions) (*InventoryLevel, error)
+	Delete(inventoryItemID int64, locationID int64) error
+}
+
+// InventoryLevelServiceOp is the default implementation of the InventoryLevelService interface
+type InventoryLevelServiceOp struct {
+	client *Client
+}
+
+// InventoryLevel represents the quantity of an inventory item stocked at a
+// location
+type InventoryLevel struct {
+	InventoryItemID   int64      `json:"inventory_item_id,omitempty"`
+	LocationID        int64      `json:"location_id,omitempty"`
+	Available         int        `json:"available"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+	AdminGraphqlAPIID string     `json:"admin_graphql_api_id,omitempty"`
+}
+
+// InventoryLevelListOptions filters the inventory levels listed, at least
+// one of InventoryItemIDs and LocationIDs is required
+type InventoryLevelListOptions struct {
+	PageInfo         string    `url:"page_info,omitempty"`
+	Limit            int       `url:"limit,omitempty"`
+	InventoryItemIDs []int64   `url:"inventory_item_ids,omitempty,comma"`
+	LocationIDs      []int64   `url:"location_ids,omitempty,comma"`
+	UpdatedAtMin     time.Time `url:"updated_at_min,omitempty"`
+}
+
+// InventoryLevelAdjustOptions adjusts the available quantity of an item at a
+// location by AvailableAdjustment, which is negative to decrease it
+type InventoryLevelAdjustOptions struct {
+	InventoryItemID     int64 `json:"inventory_item_id"`
+	LocationID          int64 `json:"location_id"`
+	AvailableAdjustment int   `json:"available_adjustment"`
+}
+
+// InventoryLevelSetOptions sets the available quantity of an item at a
+// location. DisconnectIfNecessary disconnects the item from locations it can
+// not be stocked at together with this one, e.g. fulfillment services.
+type InventoryLevelSetOptions struct {
+	InventoryItemID       int64 `json:"inventory_item_id"`
+	LocationID            int64 `json:"location_id"`
+	Available             int   `json:"available"`
+	DisconnectIfNecessary bool  `json:"disconnect_if_necessary,omitempty"`
+}
+
+// InventoryLevelConnectOptions connects an item to a location.
+// RelocateIfNecessary moves the stock of the item from locations it can not
+// be stocked at together with this one, e.g. fulfillment services.
+type InventoryLevelConnectOptions struct {
+	InventoryItemID     int64 `json:"inventory_item_id"`
+	LocationID          int64 `json:"location_id"`
+	RelocateIfNecessary bool  `json:"relocate_if_necessary,omitempty"`
+}
+
+// InventoryLevelResource is used for handling single level responses
+type InventoryLevelResource struct {
+	InventoryLevel *InventoryLevel `json:"inventory_level"`
+}
+
+// InventoryLevelsResource is used for handling multiple level responses
+type InventoryLevelsResource struct {
+	InventoryLevels []InventoryLevel `json:"inventory_levels"`
+}
+
+// List inventory levels
+func (s *InventoryLevelServiceOp) List(options interface{}) ([]InventoryLevel, error) {
+	path := fmt.Sprintf("%s.json", inventoryLevelsBasePath)
+	resource := new(InventoryLevelsResource)
+	err := s.client.Get(path, resource, options)
+	return resource.InventoryLevels, err
+}
+
+// ListWithPagination lists inventory levels and return pagination to retrieve next/previous results.
+func (s *InventoryLevelServiceOp) ListWithPagination(options interface{}) ([]InventoryLevel, *Pagination, error) {
+	path := fmt.Sprintf("%s.json", inventoryLevelsBasePath)
+	resource := new(InventoryLevelsResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.InventoryLevels, pagination, nil
+}
+
+// ListAll walks every page of inventory levels, calling fn with each page. See Client.ListAll.
+func (s *InventoryLevelServiceOp) ListAll(options interface{}, fn func([]InventoryLevel) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		levels, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(levels)
+	})
+}
+
+// Adjust the available quantity of an inventory item at a location
+func (s *InventoryLevelServiceOp) Adjust(options InventoryLevelAdjustOptions) (*InventoryLevel, error) {
+	path := fmt.Sprintf("%s/adjust.json", inventoryLevelsBasePath)
+	resource := new(InventoryLevelResource)
+	err := s.client.Post(path, options, resource)
+	return resource.InventoryLevel, err
+}
+
+// Set the available quantity of an inventory item at a location
+func (s *InventoryLevelServiceOp) Set(options InventoryLevelSetOptions) (*InventoryLevel, error) {
+	path := fmt.Sprintf("%s/set.json", inventoryLevelsBasePath)
+	resource := new(InventoryLevelResource)
+	err := s.client.Post(path, options, resource)
+	return resource.InventoryLevel, err
+}
+
+// Connect an inventory item to a location
+func (s *InventoryLevelServiceOp) Connect(options InventoryLevelConnectOptions) (*InventoryLevel, error) {
+	path := fmt.Sprintf("%s/connect.json", inventoryLevelsBasePath)
+	resource := new(InventoryLevelResource)
+	err := s.client.Post(path, options, resource)
+	return resource.InventoryLevel, err
+}
+
+// Delete the inventory level of an inventory item at a location, which
+// disconnects the item from the location
+func (s *InventoryLevelServiceOp) Delete(inventoryItemID int64, locationID int64) error {
+	path := fmt.Sprintf("%s.json", inventoryLevelsBasePath)
+	options := struct {
+		InventoryItemID int64 `url:"inventory_item_id"`
+		LocationID      int64 `url:"location_id"`
+	}{inventoryItemID, locationID}
+	return s.client.CreateAndDo("DELETE", path, nil, options, nil)
+}
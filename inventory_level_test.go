@@ -0,0 +1,177 @@
+package shopify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// inventoryLevelPostResponder checks the body posted to an inventory level
+// endpoint and answers with the inventory_level.json fixture.
+func inventoryLevelPostResponder(t *testing.T, expected string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		var sent, want map[string]interface{}
+		json.Unmarshal(body, &sent)
+		json.Unmarshal([]byte(expected), &want)
+		if !reflect.DeepEqual(sent, want) {
+			t.Errorf("%s sent %s, expected %s", req.URL.Path, body, expected)
+		}
+		return httpmock.NewBytesResponse(200, loadFixture("inventory_level.json")), nil
+	}
+}
+
+func inventoryLevelTests(t *testing.T, level *InventoryLevel) {
+	updated, _ := time.Parse(time.RFC3339, "2023-10-03T13:07:20-04:00")
+	expected := &InventoryLevel{
+		InventoryItemID:   808950810,
+		LocationID:        905684977,
+		Available:         6,
+		UpdatedAt:         &updated,
+		AdminGraphqlAPIID: "gid://shopify/InventoryLevel/905684977?inventory_item_id=808950810",
+	}
+	if !reflect.DeepEqual(level, expected) {
+		t.Errorf("InventoryLevel is %+v, expected %+v", level, expected)
+	}
+}
+
+func TestInventoryLevelList(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponderWithQuery("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_levels.json", client.pathPrefix),
+		map[string]string{"inventory_item_ids": "808950810,39072856", "location_ids": "487838322"},
+		httpmock.NewBytesResponder(200, loadFixture("inventory_levels.json")))
+
+	levels, err := client.InventoryLevel.List(InventoryLevelListOptions{
+		InventoryItemIDs: []int64{808950810, 39072856},
+		LocationIDs:      []int64{487838322},
+	})
+	if err != nil {
+		t.Errorf("InventoryLevel.List returned error: %v", err)
+	}
+
+	if len(levels) != 2 || levels[0].InventoryItemID != 808950810 || levels[1].Available != 27 {
+		t.Errorf("InventoryLevel.List returned %+v", levels)
+	}
+}
+
+func TestInventoryLevelListAll(t *testing.T) {
+	setup()
+	defer teardown()
+
+	listURL := fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_levels.json", client.pathPrefix)
+
+	first := httpmock.NewStringResponse(200, `{"inventory_levels":[{"inventory_item_id":1,"location_id":487838322,"available":3}]}`)
+	first.Header.Set("Link", fmt.Sprintf(`<%s?page_info=abc&limit=1>; rel="next"`, listURL))
+	httpmock.RegisterResponderWithQuery("GET", listURL, map[string]string{"location_ids": "487838322", "limit": "1"},
+		httpmock.ResponderFromResponse(first))
+	httpmock.RegisterResponderWithQuery("GET", listURL, map[string]string{"page_info": "abc", "limit": "1"},
+		httpmock.NewStringResponder(200, `{"inventory_levels":[{"inventory_item_id":2,"location_id":487838322,"available":5}]}`))
+
+	var levels []InventoryLevel
+	err := client.InventoryLevel.ListAll(InventoryLevelListOptions{LocationIDs: []int64{487838322}, Limit: 1}, func(page []InventoryLevel) error {
+		levels = append(levels, page...)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("InventoryLevel.ListAll returned error: %v", err)
+	}
+
+	expected := []InventoryLevel{
+		{InventoryItemID: 1, LocationID: 487838322, Available: 3},
+		{InventoryItemID: 2, LocationID: 487838322, Available: 5},
+	}
+	if !reflect.DeepEqual(levels, expected) {
+		t.Errorf("InventoryLevel.ListAll returned %+v, expected %+v", levels, expected)
+	}
+}
+
+func TestInventoryLevelAdjust(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_levels/adjust.json", client.pathPrefix),
+		inventoryLevelPostResponder(t, `{"inventory_item_id":808950810,"location_id":905684977,"available_adjustment":-2}`))
+
+	level, err := client.InventoryLevel.Adjust(InventoryLevelAdjustOptions{
+		InventoryItemID:     808950810,
+		LocationID:          905684977,
+		AvailableAdjustment: -2,
+	})
+	if err != nil {
+		t.Errorf("InventoryLevel.Adjust returned error: %v", err)
+	}
+
+	inventoryLevelTests(t, level)
+}
+
+func TestInventoryLevelSet(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_levels/set.json", client.pathPrefix),
+		inventoryLevelPostResponder(t, `{"inventory_item_id":808950810,"location_id":905684977,"available":6,"disconnect_if_necessary":true}`))
+
+	level, err := client.InventoryLevel.Set(InventoryLevelSetOptions{
+		InventoryItemID:       808950810,
+		LocationID:            905684977,
+		Available:             6,
+		DisconnectIfNecessary: true,
+	})
+	if err != nil {
+		t.Errorf("InventoryLevel.Set returned error: %v", err)
+	}
+
+	inventoryLevelTests(t, level)
+}
+
+func TestInventoryLevelSetZero(t *testing.T) {
+	setup()
+	defer teardown()
+
+	// an available quantity of 0 must be sent
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_levels/set.json", client.pathPrefix),
+		inventoryLevelPostResponder(t, `{"inventory_item_id":808950810,"location_id":905684977,"available":0}`))
+
+	if _, err := client.InventoryLevel.Set(InventoryLevelSetOptions{InventoryItemID: 808950810, LocationID: 905684977}); err != nil {
+		t.Errorf("InventoryLevel.Set returned error: %v", err)
+	}
+}
+
+func TestInventoryLevelConnect(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_levels/connect.json", client.pathPrefix),
+		inventoryLevelPostResponder(t, `{"inventory_item_id":808950810,"location_id":905684977}`))
+
+	level, err := client.InventoryLevel.Connect(InventoryLevelConnectOptions{
+		InventoryItemID: 808950810,
+		LocationID:      905684977,
+	})
+	if err != nil {
+		t.Errorf("InventoryLevel.Connect returned error: %v", err)
+	}
+
+	inventoryLevelTests(t, level)
+}
+
+func TestInventoryLevelDelete(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponderWithQuery("DELETE", fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_levels.json", client.pathPrefix),
+		map[string]string{"inventory_item_id": "808950810", "location_id": "905684977"},
+		httpmock.NewStringResponder(204, ""))
+
+	if err := client.InventoryLevel.Delete(808950810, 905684977); err != nil {
+		t.Errorf("InventoryLevel.Delete returned error: %v", err)
+	}
+}
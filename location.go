@@ -7,16 +7,31 @@ import (
 
 const locationsBasePath = "locations"
 
+// maximum number of inventory items requested at once by StockBySKU
+const stockBySKUBatchSize = 100
+
 // LocationService is an interface for interfacing with the location endpoints
 // of the Shopify API.
 // See: https://help.shopify.com/en/api/reference/inventory/location
 type LocationService interface {
 	// Retrieves a list of locations
 	List(options interface{}) ([]Location, error)
+	// Retrieves a list of locations and pagination to retrieve next/previous results
+	ListWithPagination(options interface{}) ([]Location, *Pagination, error)
+	// Walks every page of locations
+	ListAll(options interface{}, fn func([]Location) error) error
 	// Retrieves a single location by its ID
 	Get(ID int64, options interface{}) (*Location, error)
 	// Retrieves a count of locations
 	Count(options interface{}) (int, error)
+	// Retrieves the inventory levels of a location
+	ListInventoryLevels(locationID int64, options interface{}) ([]InventoryLevel, error)
+	// Retrieves the inventory levels of a location and pagination to retrieve next/previous results
+	ListInventoryLevelsWithPagination(locationID int64, options interface{}) ([]InventoryLevel, *Pagination, error)
+	// Walks every page of inventory levels of a location
+	ListAllInventoryLevels(locationID int64, options interface{}, fn func([]InventoryLevel) error) error
+	// Retrieves the available quantity of every SKU stocked at a location
+	StockBySKU(locationID int64) (map[string]int, error)
 }
 
 type Location struct {
@@ -87,6 +102,30 @@ func (s *LocationServiceOp) List(options interface{}) ([]Location, error) {
 	return resource.Locations, err
 }
 
+// ListWithPagination lists locations and return pagination to retrieve next/previous results.
+func (s *LocationServiceOp) ListWithPagination(options interface{}) ([]Location, *Pagination, error) {
+	path := fmt.Sprintf("%s.json", locationsBasePath)
+	resource := new(LocationsResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.Locations, pagination, nil
+}
+
+// ListAll walks every page of locations, calling fn with each page. See Client.ListAll.
+func (s *LocationServiceOp) ListAll(options interface{}, fn func([]Location) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		locations, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(locations)
+	})
+}
+
 func (s *LocationServiceOp) Get(ID int64, options interface{}) (*Location, error) {
 	path := fmt.Sprintf("%s/%d.json", locationsBasePath, ID)
 	resource := new(LocationResource)
@@ -99,6 +138,82 @@ func (s *LocationServiceOp) Count(options interface{}) (int, error) {
 	return s.client.Count(path, options)
 }
 
+// ListInventoryLevels lists the inventory levels of a location.
+func (s *LocationServiceOp) ListInventoryLevels(locationID int64, options interface{}) ([]InventoryLevel, error) {
+	path := fmt.Sprintf("%s/%d/%s.json", locationsBasePath, locationID, inventoryLevelsBasePath)
+	resource := new(InventoryLevelsResource)
+	err := s.client.Get(path, resource, options)
+	return resource.InventoryLevels, err
+}
+
+// ListInventoryLevelsWithPagination lists the inventory levels of a location and return pagination to retrieve next/previous results.
+func (s *LocationServiceOp) ListInventoryLevelsWithPagination(locationID int64, options interface{}) ([]InventoryLevel, *Pagination, error) {
+	path := fmt.Sprintf("%s/%d/%s.json", locationsBasePath, locationID, inventoryLevelsBasePath)
+	resource := new(InventoryLevelsResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.InventoryLevels, pagination, nil
+}
+
+// ListAllInventoryLevels walks every page of inventory levels of a location, calling fn with each page. See Client.ListAll.
+func (s *LocationServiceOp) ListAllInventoryLevels(locationID int64, options interface{}, fn func([]InventoryLevel) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		levels, pagination, err := s.ListInventoryLevelsWithPagination(locationID, options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(levels)
+	})
+}
+
+// StockBySKU returns the available quantity of every SKU stocked at a
+// location, joining its inventory levels with the SKUs of their inventory
+// items. Items without a SKU are left out, the quantities of items sharing a
+// SKU are summed.
+func (s *LocationServiceOp) StockBySKU(locationID int64) (map[string]int, error) {
+	available := map[int64]int{}
+	var itemIDs []int64
+	err := s.ListAllInventoryLevels(locationID, ListOptions{Limit: 250}, func(levels []InventoryLevel) error {
+		for _, level := range levels {
+			if _, ok := available[level.InventoryItemID]; !ok {
+				itemIDs = append(itemIDs, level.InventoryItemID)
+			}
+			available[level.InventoryItemID] += level.Available
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stock := map[string]int{}
+	for start := 0; start < len(itemIDs); start += stockBySKUBatchSize {
+		end := start + stockBySKUBatchSize
+		if end > len(itemIDs) {
+			end = len(itemIDs)
+		}
+
+		options := ListOptions{IDs: itemIDs[start:end], Limit: stockBySKUBatchSize}
+		err := s.client.InventoryItem.ListAll(options, func(items []InventoryItem) error {
+			for _, item := range items {
+				if item.SKU != "" {
+					stock[item.SKU] += available[item.ID]
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return stock, nil
+}
+
 // Represents the result from the locations/X.json endpoint
 type LocationResource struct {
 	Location *Location `json:"location"`
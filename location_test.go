@@ -102,3 +102,81 @@ func TestLocationServiceOp_Count(t *testing.T) {
 		t.Errorf("Location.Count returned %d, expected %d", cnt, expected)
 	}
 }
+
+func TestLocationServiceOp_ListInventoryLevels(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/locations/487838322/inventory_levels.json", client.pathPrefix),
+		httpmock.NewBytesResponder(200, loadFixture("inventory_levels.json")))
+
+	levels, err := client.Location.ListInventoryLevels(487838322, nil)
+	if err != nil {
+		t.Errorf("Location.ListInventoryLevels returned error: %v", err)
+	}
+
+	if len(levels) != 2 || levels[0].InventoryItemID != 808950810 || levels[0].Available != 9 || levels[1].LocationID != 487838322 {
+		t.Errorf("Location.ListInventoryLevels returned %+v", levels)
+	}
+}
+
+func TestLocationServiceOp_ListInventoryLevelsWithPagination(t *testing.T) {
+	setup()
+	defer teardown()
+
+	listURL := fmt.Sprintf("https://fooshop.myshopify.com/%s/locations/487838322/inventory_levels.json", client.pathPrefix)
+	response := httpmock.NewBytesResponse(200, loadFixture("inventory_levels.json"))
+	response.Header.Set("Link", fmt.Sprintf(`<%s?page_info=abc&limit=2>; rel="next"`, listURL))
+	httpmock.RegisterResponder("GET", listURL, httpmock.ResponderFromResponse(response))
+
+	levels, pagination, err := client.Location.ListInventoryLevelsWithPagination(487838322, ListOptions{Limit: 2})
+	if err != nil {
+		t.Errorf("Location.ListInventoryLevelsWithPagination returned error: %v", err)
+	}
+
+	if len(levels) != 2 {
+		t.Errorf("Location.ListInventoryLevelsWithPagination returned %d levels, expected 2", len(levels))
+	}
+	expected := &Pagination{NextPageOptions: &ListOptions{PageInfo: "abc", Limit: 2}}
+	if !reflect.DeepEqual(pagination, expected) {
+		t.Errorf("Location.ListInventoryLevelsWithPagination returned pagination %+v, expected %+v", pagination, expected)
+	}
+}
+
+func TestLocationServiceOp_StockBySKU(t *testing.T) {
+	setup()
+	defer teardown()
+
+	levelsURL := fmt.Sprintf("https://fooshop.myshopify.com/%s/locations/487838322/inventory_levels.json", client.pathPrefix)
+	first := httpmock.NewStringResponse(200, `{"inventory_levels":[
+		{"inventory_item_id":1,"location_id":487838322,"available":3},
+		{"inventory_item_id":2,"location_id":487838322,"available":5}
+	]}`)
+	first.Header.Set("Link", fmt.Sprintf(`<%s?page_info=abc&limit=250>; rel="next"`, levelsURL))
+	httpmock.RegisterResponderWithQuery("GET", levelsURL, map[string]string{"limit": "250"},
+		httpmock.ResponderFromResponse(first))
+	httpmock.RegisterResponderWithQuery("GET", levelsURL, map[string]string{"page_info": "abc", "limit": "250"},
+		httpmock.NewStringResponder(200, `{"inventory_levels":[
+			{"inventory_item_id":3,"location_id":487838322,"available":-1},
+			{"inventory_item_id":4,"location_id":487838322,"available":7}
+		]}`))
+
+	httpmock.RegisterResponderWithQuery("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/inventory_items.json", client.pathPrefix),
+		map[string]string{"ids": "1,2,3,4", "limit": "100"},
+		httpmock.NewStringResponder(200, `{"inventory_items":[
+			{"id":1,"sku":"SHIRT-S"},
+			{"id":2,"sku":"SHIRT-M"},
+			{"id":3,"sku":"SHIRT-S"},
+			{"id":4,"sku":""}
+		]}`))
+
+	stock, err := client.Location.StockBySKU(487838322)
+	if err != nil {
+		t.Errorf("Location.StockBySKU returned error: %v", err)
+	}
+
+	expected := map[string]int{"SHIRT-S": 2, "SHIRT-M": 5}
+	if !reflect.DeepEqual(stock, expected) {
+		t.Errorf("Location.StockBySKU returned %v, expected %v", stock, expected)
+	}
+}
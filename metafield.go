@@ -10,6 +10,8 @@ import (
 // https://help.shopify.com/api/reference/metafield
 type MetafieldService interface {
 	List(interface{}) ([]Metafield, error)
+	ListWithPagination(interface{}) ([]Metafield, *Pagination, error)
+	ListAll(interface{}, func([]Metafield) error) error
 	Count(interface{}) (int, error)
 	Get(int64, interface{}) (*Metafield, error)
 	Create(Metafield) (*Metafield, error)
@@ -71,6 +73,31 @@ func (s *MetafieldServiceOp) List(options interface{}) ([]Metafield, error) {
 	return resource.Metafields, err
 }
 
+// ListWithPagination lists metafields and return pagination to retrieve next/previous results.
+func (s *MetafieldServiceOp) ListWithPagination(options interface{}) ([]Metafield, *Pagination, error) {
+	prefix := MetafieldPathPrefix(s.resource, s.resourceID)
+	path := fmt.Sprintf("%s.json", prefix)
+	resource := new(MetafieldsResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.Metafields, pagination, nil
+}
+
+// ListAll walks every page of metafields, calling fn with each page. See Client.ListAll.
+func (s *MetafieldServiceOp) ListAll(options interface{}, fn func([]Metafield) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		metafields, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(metafields)
+	})
+}
+
 // Count metafields
 func (s *MetafieldServiceOp) Count(options interface{}) (int, error) {
 	prefix := MetafieldPathPrefix(s.resource, s.resourceID)
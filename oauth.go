@@ -2,6 +2,7 @@ package shopify
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -11,17 +12,31 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 const shopifyChecksumHeader = "X-Shopify-Hmac-Sha256"
 
 var accessTokenRelPath = "admin/oauth/access_token"
 
+// AuthorizeOption is used to configure the url returned by AuthorizeUrl.
+type AuthorizeOption func(query url.Values)
+
+// WithPerUserGrant requests an online access token, tied to the staff member
+// approving the app and expiring with their session, instead of a permanent
+// one.
+// See: https://shopify.dev/apps/auth/oauth/access-modes
+func WithPerUserGrant() AuthorizeOption {
+	return func(query url.Values) {
+		query.Add("grant_options[]", "per-user")
+	}
+}
+
 // Returns a Shopify oauth authorization url for the given shopname and state.
 //
 // State is a unique value that can be used to check the authenticity during a
 // callback from Shopify.
-func (app App) AuthorizeUrl(shopName string, state string) string {
+func (app App) AuthorizeUrl(shopName string, state string, opts ...AuthorizeOption) string {
 	shopUrl, _ := url.Parse(ShopBaseUrl(shopName))
 	shopUrl.Path = "/admin/oauth/authorize"
 	query := shopUrl.Query()
@@ -29,15 +44,41 @@ func (app App) AuthorizeUrl(shopName string, state string) string {
 	query.Set("redirect_uri", app.RedirectUrl)
 	query.Set("scope", app.Scope)
 	query.Set("state", state)
+	for _, opt := range opts {
+		opt(query)
+	}
 	shopUrl.RawQuery = query.Encode()
 	return shopUrl.String()
 }
 
+// GetAccessToken exchanges the code Shopify passed to the redirect url for a
+// permanent access token, see RequestAccessToken.
 func (app App) GetAccessToken(shopName string, code string) (string, error) {
-	type Token struct {
-		Token string `json:"access_token"`
+	token, err := app.RequestAccessToken(shopName, code)
+	if err != nil {
+		return "", err
+	}
+	return token.Token, nil
+}
+
+// RequestAccessToken exchanges the code Shopify passed to the redirect url
+// for an access token. Permanent tokens are saved into the TokenStore of the
+// app, if it has one. Online tokens, see WithPerUserGrant, are not: they
+// belong to a staff member's session rather than to the shop.
+func (app App) RequestAccessToken(shopName string, code string) (*AccessToken, error) {
+	token, err := app.requestAccessToken(app.context(), shopName, code)
+	if err != nil {
+		return nil, err
 	}
+	if err := app.storeToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
 
+// requestAccessToken exchanges the code for an access token without saving
+// it, so OAuthHandler can check its scopes first.
+func (app App) requestAccessToken(ctx context.Context, shopName string, code string) (*AccessToken, error) {
 	data := struct {
 		ClientId     string `json:"client_id"`
 		ClientSecret string `json:"client_secret"`
@@ -48,19 +89,105 @@ func (app App) GetAccessToken(shopName string, code string) (string, error) {
 		Code:         code,
 	}
 
+	return app.requestToken(ctx, shopName, data)
+}
+
+// TokenType is the type of access token requested by ExchangeSessionToken.
+type TokenType string
+
+const (
+	OfflineAccessToken TokenType = "urn:shopify:params:oauth:token-type:offline-access-token"
+	OnlineAccessToken  TokenType = "urn:shopify:params:oauth:token-type:online-access-token"
+)
+
+const (
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	idTokenType            = "urn:ietf:params:oauth:token-type:id_token"
+)
+
+// ExchangeSessionToken exchanges a session token of an embedded app for an
+// access token of the requested type, without redirecting the merchant
+// through the OAuth flow. The session token should be verified first, see
+// VerifySessionToken, which also tells the shop it was issued by. Tokens are
+// saved into the TokenStore of the app like those of RequestAccessToken.
+// See: https://shopify.dev/apps/auth/get-access-tokens/token-exchange
+func (app App) ExchangeSessionToken(shopName string, sessionToken string, tokenType TokenType) (*AccessToken, error) {
+	return app.ExchangeSessionTokenWithContext(app.context(), shopName, sessionToken, tokenType)
+}
+
+// ExchangeSessionTokenWithContext is like ExchangeSessionToken but the
+// request is bound to ctx, usually the context of the request carrying the
+// session token.
+func (app App) ExchangeSessionTokenWithContext(ctx context.Context, shopName string, sessionToken string, tokenType TokenType) (*AccessToken, error) {
+	data := struct {
+		ClientId           string    `json:"client_id"`
+		ClientSecret       string    `json:"client_secret"`
+		GrantType          string    `json:"grant_type"`
+		SubjectToken       string    `json:"subject_token"`
+		SubjectTokenType   string    `json:"subject_token_type"`
+		RequestedTokenType TokenType `json:"requested_token_type"`
+	}{
+		ClientId:           app.ApiKey,
+		ClientSecret:       app.ApiSecret,
+		GrantType:          tokenExchangeGrantType,
+		SubjectToken:       sessionToken,
+		SubjectTokenType:   idTokenType,
+		RequestedTokenType: tokenType,
+	}
+
+	token, err := app.requestToken(ctx, shopName, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := app.storeToken(token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// requestToken posts data to the access token endpoint of the shop and
+// returns the token it is answered with. The request is bound to ctx.
+func (app App) requestToken(ctx context.Context, shopName string, data interface{}) (*AccessToken, error) {
 	client := app.Client
 	if client == nil {
 		client = NewClient(app, shopName, "")
 	}
 
-	req, err := client.NewRequest("POST", accessTokenRelPath, data, nil)
+	req, err := client.NewRequestWithContext(ctx, "POST", accessTokenRelPath, data, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	token := new(Token)
+	token := new(AccessToken)
 	err = client.Do(req, token)
-	return token.Token, err
+	if err != nil {
+		return nil, err
+	}
+
+	token.Shop = shopKey(shopName)
+	token.CreatedAt = time.Now()
+	if token.ExpiresIn > 0 {
+		token.ExpiresAt = token.CreatedAt.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
+
+// context returns the context of the Client of the app, if it has one.
+func (app App) context() context.Context {
+	if app.Client != nil {
+		return app.Client.context()
+	}
+	return context.Background()
+}
+
+// storeToken saves a permanent token into the TokenStore of the app, if it
+// has one.
+func (app App) storeToken(token *AccessToken) error {
+	if app.TokenStore == nil || token.Online() {
+		return nil
+	}
+	return app.TokenStore.Save(*token)
 }
 
 // Verify a message against a message HMAC
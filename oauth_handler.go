@@ -0,0 +1,268 @@
+package shopify
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultOAuthStateCookie is the name of the cookie holding the state of
+	// an install.
+	DefaultOAuthStateCookie = "shopify_oauth_state"
+	// oauthStateTTL is how long a merchant has to approve an install.
+	oauthStateTTL = 10 * time.Minute
+)
+
+// shopDomainRegex matches valid myshopify domains.
+var shopDomainRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9\-]*\.myshopify\.com$`)
+
+// ValidShopDomain reports whether shop is a myshopify domain, e.g.
+// "theshop.myshopify.com". Shop names taken from requests must be validated
+// before sending anything to them.
+func ValidShopDomain(shop string) bool {
+	return shopDomainRegex.MatchString(shop)
+}
+
+// ScopeError is returned when a token was not granted every scope the app
+// requested.
+type ScopeError struct {
+	Missing []string
+}
+
+func (e ScopeError) Error() string {
+	return fmt.Sprintf("shopify: scopes not granted: %s", strings.Join(e.Missing, ", "))
+}
+
+// MissingScopes returns the scopes of requested, a comma separated list as
+// in App.Scope, that granted does not cover. A write scope covers the read
+// scope of the same resource, Shopify only reports the former when both are
+// granted.
+func MissingScopes(requested string, granted []string) []string {
+	has := map[string]bool{}
+	for _, scope := range granted {
+		scope = strings.TrimSpace(scope)
+		has[scope] = true
+		if strings.HasPrefix(scope, "write_") {
+			has["read_"+strings.TrimPrefix(scope, "write_")] = true
+		}
+		if strings.HasPrefix(scope, "unauthenticated_write_") {
+			has["unauthenticated_read_"+strings.TrimPrefix(scope, "unauthenticated_write_")] = true
+		}
+	}
+
+	var missing []string
+	for _, scope := range strings.Split(requested, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope != "" && !has[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// OAuthHandler implements the OAuth install flow of an app. Its install
+// handler redirects the merchant to Shopify to approve the app, its callback
+// handler verifies Shopify's redirect, exchanges the code for an access
+// token and passes the token to OnInstall.
+//
+// The state of each install is a random nonce kept in a cookie signed with
+// the app's ApiSecret, so installs can not be forged across sites.
+//
+//	oauth := shopify.NewOAuthHandler(app, func(w http.ResponseWriter, r *http.Request, token *shopify.AccessToken) {
+//		http.Redirect(w, r, "/welcome?shop="+token.Shop, http.StatusFound)
+//	})
+//	http.Handle("/install", oauth.InstallHandler())
+//	http.Handle("/callback", oauth.CallbackHandler())
+type OAuthHandler struct {
+	App App
+
+	// OnInstall is called with the token once the install completed, it
+	// writes the response, e.g. redirecting to the app.
+	OnInstall func(w http.ResponseWriter, r *http.Request, token *AccessToken)
+
+	// OnError is called when the install failed with the status to answer
+	// with. It defaults to answering with the status text only, so details
+	// are not leaked.
+	OnError func(w http.ResponseWriter, r *http.Request, err error, status int)
+
+	// CookieName of the state cookie, defaults to DefaultOAuthStateCookie.
+	CookieName string
+	// InsecureCookie lets the state cookie be sent over http, for local
+	// development only.
+	InsecureCookie bool
+
+	// OnlineAccess requests online tokens, granted to the staff member
+	// installing the app, instead of permanent ones. See WithPerUserGrant.
+	OnlineAccess bool
+}
+
+// NewOAuthHandler returns an OAuthHandler for app calling onInstall with the
+// tokens it obtains. The tokens are saved into the TokenStore of app, if it
+// has one.
+func NewOAuthHandler(app App, onInstall func(w http.ResponseWriter, r *http.Request, token *AccessToken)) *OAuthHandler {
+	return &OAuthHandler{
+		App:       app,
+		OnInstall: onInstall,
+	}
+}
+
+// InstallHandler returns the handler starting an install. It expects the
+// shop in the "shop" query parameter, as sent by Shopify when a merchant
+// installs the app.
+func (h *OAuthHandler) InstallHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		shop := strings.ToLower(query.Get("shop"))
+		if !ValidShopDomain(shop) {
+			h.fail(w, r, fmt.Errorf("shopify: invalid shop %q", shop), http.StatusBadRequest)
+			return
+		}
+
+		// requests coming from Shopify are signed, others, e.g. from an
+		// install form, are not
+		if query.Get("hmac") != "" {
+			if ok, _ := h.App.VerifyAuthorizationURL(r.URL); !ok {
+				h.fail(w, r, fmt.Errorf("shopify: invalid hmac"), http.StatusUnauthorized)
+				return
+			}
+		}
+
+		nonce, err := newOAuthNonce()
+		if err != nil {
+			h.fail(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     h.cookieName(),
+			Value:    nonce + "." + h.sign(nonce),
+			Path:     "/",
+			MaxAge:   int(oauthStateTTL / time.Second),
+			Secure:   !h.InsecureCookie,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		var opts []AuthorizeOption
+		if h.OnlineAccess {
+			opts = append(opts, WithPerUserGrant())
+		}
+		http.Redirect(w, r, h.App.AuthorizeUrl(shop, nonce, opts...), http.StatusFound)
+	})
+}
+
+// CallbackHandler returns the handler Shopify redirects the merchant to,
+// the RedirectUrl of the app. It checks the shop, the hmac and the state of
+// the request, exchanges the code for a token, checks the token was granted
+// the scopes of the app, saves it into the TokenStore of the app and calls
+// OnInstall.
+func (h *OAuthHandler) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		shop := strings.ToLower(query.Get("shop"))
+		if !ValidShopDomain(shop) {
+			h.fail(w, r, fmt.Errorf("shopify: invalid shop %q", shop), http.StatusBadRequest)
+			return
+		}
+
+		if ok, _ := h.App.VerifyAuthorizationURL(r.URL); !ok {
+			h.fail(w, r, fmt.Errorf("shopify: invalid hmac"), http.StatusUnauthorized)
+			return
+		}
+
+		if !h.verifyState(r, query.Get("state")) {
+			h.fail(w, r, fmt.Errorf("shopify: invalid state"), http.StatusForbidden)
+			return
+		}
+
+		// the state is used once
+		http.SetCookie(w, &http.Cookie{
+			Name:     h.cookieName(),
+			Path:     "/",
+			MaxAge:   -1,
+			Secure:   !h.InsecureCookie,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		// a merchant leaving the page cancels the exchange
+		token, err := h.App.requestAccessToken(r.Context(), shop, query.Get("code"))
+		if err != nil {
+			h.fail(w, r, err, http.StatusBadGateway)
+			return
+		}
+
+		// an under-scoped token is not stored, clients would use it as if
+		// the install had succeeded
+		if missing := MissingScopes(h.App.Scope, token.Scopes()); len(missing) > 0 {
+			h.fail(w, r, ScopeError{Missing: missing}, http.StatusForbidden)
+			return
+		}
+
+		if err := h.App.storeToken(token); err != nil {
+			h.fail(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		h.OnInstall(w, r, token)
+	})
+}
+
+// verifyState reports whether state matches the signed nonce of the state
+// cookie.
+func (h *OAuthHandler) verifyState(r *http.Request, state string) bool {
+	cookie, err := r.Cookie(h.cookieName())
+	if err != nil || state == "" {
+		return false
+	}
+
+	parts := strings.SplitN(cookie.Value, ".", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	nonce, signature := parts[0], parts[1]
+
+	if !hmac.Equal([]byte(signature), []byte(h.sign(nonce))) {
+		return false
+	}
+	return hmac.Equal([]byte(nonce), []byte(state))
+}
+
+// sign returns the hex encoded signature of nonce.
+func (h *OAuthHandler) sign(nonce string) string {
+	mac := hmac.New(sha256.New, []byte(h.App.ApiSecret))
+	mac.Write([]byte(nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *OAuthHandler) cookieName() string {
+	if h.CookieName != "" {
+		return h.CookieName
+	}
+	return DefaultOAuthStateCookie
+}
+
+func (h *OAuthHandler) fail(w http.ResponseWriter, r *http.Request, err error, status int) {
+	if h.OnError != nil {
+		h.OnError(w, r, err, status)
+		return
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// newOAuthNonce returns a random hex encoded nonce.
+func newOAuthNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,249 @@
+package shopify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// signQuery adds the hmac Shopify would compute for query.
+func signQuery(secret string, query url.Values) string {
+	message, _ := url.QueryUnescape(query.Encode())
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	query.Set("hmac", hex.EncodeToString(mac.Sum(nil)))
+	return query.Encode()
+}
+
+func TestValidShopDomain(t *testing.T) {
+	cases := map[string]bool{
+		"fooshop.myshopify.com":          true,
+		"foo-shop-2.myshopify.com":       true,
+		"fooshop":                        false,
+		"fooshop.myshopify.com.evil.com": false,
+		"evil.com/fooshop.myshopify.com": false,
+		"-fooshop.myshopify.com":         false,
+		"":                               false,
+	}
+	for shop, expected := range cases {
+		if ValidShopDomain(shop) != expected {
+			t.Errorf("ValidShopDomain(%q): expected %v", shop, expected)
+		}
+	}
+}
+
+func TestMissingScopes(t *testing.T) {
+	cases := []struct {
+		requested string
+		granted   []string
+		expected  []string
+	}{
+		{"read_products,write_orders", []string{"read_products", "write_orders"}, nil},
+		{"read_products, read_orders", []string{"write_products", "write_orders"}, nil},
+		{"write_products,read_orders", []string{"read_products"}, []string{"read_orders", "write_products"}},
+		{"unauthenticated_read_checkouts", []string{"unauthenticated_write_checkouts"}, nil},
+		{"", nil, nil},
+	}
+	for _, c := range cases {
+		if actual := MissingScopes(c.requested, c.granted); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("MissingScopes(%q, %v) = %v, expected %v", c.requested, c.granted, actual, c.expected)
+		}
+	}
+}
+
+func TestOAuthHandlerInstall(t *testing.T) {
+	setup()
+	defer teardown()
+
+	handler := NewOAuthHandler(app, nil).InstallHandler()
+
+	req := httptest.NewRequest("GET", "/install?shop=fooshop.myshopify.com", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("install answered %d, expected 302", w.Code)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultOAuthStateCookie || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("install set cookies %#v", cookies)
+	}
+
+	location, _ := url.Parse(w.Header().Get("Location"))
+	state := location.Query().Get("state")
+	if state == "" || !strings.HasPrefix(cookies[0].Value, state+".") {
+		t.Errorf("state %q does not match cookie %q", state, cookies[0].Value)
+	}
+	if expected := app.AuthorizeUrl("fooshop.myshopify.com", state); location.String() != expected {
+		t.Errorf("install redirected to %s, expected %s", location, expected)
+	}
+
+	cases := []struct {
+		url    string
+		status int
+	}{
+		{"/install?shop=evil.com", http.StatusBadRequest},
+		{"/install", http.StatusBadRequest},
+		{"/install?shop=fooshop.myshopify.com&hmac=abcd&timestamp=1", http.StatusUnauthorized},
+		{"/install?" + signQuery(app.ApiSecret, url.Values{"shop": {"fooshop.myshopify.com"}, "timestamp": {"1"}}), http.StatusFound},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", c.url, nil))
+		if w.Code != c.status {
+			t.Errorf("install %s answered %d, expected %d", c.url, w.Code, c.status)
+		}
+	}
+}
+
+func TestOAuthHandlerInstallOnline(t *testing.T) {
+	setup()
+	defer teardown()
+
+	h := NewOAuthHandler(app, nil)
+	h.OnlineAccess = true
+
+	w := httptest.NewRecorder()
+	h.InstallHandler().ServeHTTP(w, httptest.NewRequest("GET", "/install?shop=fooshop.myshopify.com", nil))
+
+	location, _ := url.Parse(w.Header().Get("Location"))
+	if grant := location.Query().Get("grant_options[]"); grant != "per-user" {
+		t.Errorf("install redirected with grant_options[] %q, expected per-user", grant)
+	}
+}
+
+func TestOAuthHandlerCallback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", "https://fooshop.myshopify.com/admin/oauth/access_token",
+		httpmock.NewStringResponder(http.StatusOK, `{"access_token": "footoken", "scope": "write_products"}`))
+
+	store := NewMemoryTokenStore()
+	a := app
+	a.Client = client
+	a.TokenStore = store
+
+	var installed *AccessToken
+	oauth := NewOAuthHandler(a, func(w http.ResponseWriter, r *http.Request, token *AccessToken) {
+		installed = token
+		w.WriteHeader(http.StatusNoContent)
+	})
+	var failure error
+	oauth.OnError = func(w http.ResponseWriter, r *http.Request, err error, status int) {
+		failure = err
+		w.WriteHeader(status)
+	}
+
+	// start an install to get a state cookie
+	w := httptest.NewRecorder()
+	oauth.InstallHandler().ServeHTTP(w, httptest.NewRequest("GET", "/install?shop=fooshop.myshopify.com", nil))
+	cookie := w.Result().Cookies()[0]
+	location, _ := url.Parse(w.Header().Get("Location"))
+	state := location.Query().Get("state")
+
+	callback := func(query url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/callback?"+signQuery(a.ApiSecret, query), nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		oauth.CallbackHandler().ServeHTTP(w, req)
+		return w
+	}
+	values := func(shop, state string) url.Values {
+		return url.Values{"code": {"foocode"}, "shop": {shop}, "state": {state}, "timestamp": {"1337178173"}}
+	}
+
+	forged := *cookie
+	forged.Value = "abcd." + strings.SplitN(cookie.Value, ".", 2)[1]
+
+	cases := []struct {
+		name   string
+		query  url.Values
+		cookie *http.Cookie
+		status int
+	}{
+		{"invalid shop", values("evil.com", state), cookie, http.StatusBadRequest},
+		{"no cookie", values("fooshop.myshopify.com", state), nil, http.StatusForbidden},
+		{"wrong state", values("fooshop.myshopify.com", "abcd"), cookie, http.StatusForbidden},
+		{"forged cookie", values("fooshop.myshopify.com", "abcd"), &forged, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if w := callback(c.query, c.cookie); w.Code != c.status {
+			t.Errorf("%s: callback answered %d, expected %d", c.name, w.Code, c.status)
+		}
+	}
+
+	// a tampered query fails the hmac check
+	req := httptest.NewRequest("GET", "/callback?"+signQuery(a.ApiSecret, values("fooshop.myshopify.com", state))+"&extra=1", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	oauth.CallbackHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("tampered query: callback answered %d, expected 401", w.Code)
+	}
+
+	// the exchange is bound to the context of the callback request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequest("GET", "/callback?"+signQuery(a.ApiSecret, values("fooshop.myshopify.com", state)), nil).WithContext(ctx)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	oauth.CallbackHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusBadGateway || !errors.Is(failure, context.Canceled) {
+		t.Errorf("cancelled request: callback answered %d with %v, expected 502 for context.Canceled", w.Code, failure)
+	}
+	if calls := httpmock.GetCallCountInfo()["POST https://fooshop.myshopify.com/admin/oauth/access_token"]; calls != 0 {
+		t.Errorf("cancelled request: code exchanged %d times", calls)
+	}
+
+	if installed != nil {
+		t.Fatalf("OnInstall called for a failed install %#v", installed)
+	}
+
+	// write_products covers the requested read_products
+	w = callback(values("fooshop.myshopify.com", state), cookie)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("callback answered %d, expected 204: %v", w.Code, failure)
+	}
+	if installed == nil || installed.Token != "footoken" || installed.Shop != "fooshop.myshopify.com" {
+		t.Errorf("OnInstall called with %#v", installed)
+	}
+	if stored, err := store.Load("fooshop"); err != nil || stored.Token != "footoken" {
+		t.Errorf("token was not stored %#v, %v", stored, err)
+	}
+	if cleared := w.Result().Cookies(); len(cleared) != 1 || cleared[0].MaxAge >= 0 {
+		t.Errorf("callback did not clear the state cookie %#v", cleared)
+	}
+
+	// scopes not granted
+	oauth.App.Scope = "read_products,read_orders"
+	installed = nil
+	w = callback(values("fooshop.myshopify.com", state), cookie)
+	var scopeErr ScopeError
+	if w.Code != http.StatusForbidden || !errors.As(failure, &scopeErr) || !reflect.DeepEqual(scopeErr.Missing, []string{"read_orders"}) {
+		t.Errorf("callback answered %d with %v, expected 403 for missing read_orders", w.Code, failure)
+	}
+	if installed != nil {
+		t.Error("OnInstall called although scopes are missing")
+	}
+
+	// the under-scoped token of a new install is not stored
+	store.Delete("fooshop")
+	callback(values("fooshop.myshopify.com", state), cookie)
+	if shops, _ := store.Shops(); len(shops) != 0 {
+		t.Errorf("token stored although scopes are missing, store holds %v", shops)
+	}
+}
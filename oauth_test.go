@@ -1,13 +1,18 @@
 package shopify
 
 import (
+	"context"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 )
@@ -19,13 +24,15 @@ func TestAppAuthorizeUrl(t *testing.T) {
 	cases := []struct {
 		shopName string
 		nonce    string
+		opts     []AuthorizeOption
 		expected string
 	}{
-		{"fooshop", "thenonce", "https://fooshop.myshopify.com/admin/oauth/authorize?client_id=apikey&redirect_uri=https%3A%2F%2Fexample.com%2Fcallback&scope=read_products&state=thenonce"},
+		{"fooshop", "thenonce", nil, "https://fooshop.myshopify.com/admin/oauth/authorize?client_id=apikey&redirect_uri=https%3A%2F%2Fexample.com%2Fcallback&scope=read_products&state=thenonce"},
+		{"fooshop", "thenonce", []AuthorizeOption{WithPerUserGrant()}, "https://fooshop.myshopify.com/admin/oauth/authorize?client_id=apikey&grant_options%5B%5D=per-user&redirect_uri=https%3A%2F%2Fexample.com%2Fcallback&scope=read_products&state=thenonce"},
 	}
 
 	for _, c := range cases {
-		actual := app.AuthorizeUrl(c.shopName, c.nonce)
+		actual := app.AuthorizeUrl(c.shopName, c.nonce, c.opts...)
 		if actual != c.expected {
 			t.Errorf("App.AuthorizeUrl(): expected %s, actual %s", c.expected, actual)
 		}
@@ -52,6 +59,172 @@ func TestAppGetAccessToken(t *testing.T) {
 	}
 }
 
+func TestAppRequestAccessToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", "https://fooshop.myshopify.com/admin/oauth/access_token",
+		httpmock.NewStringResponder(200, `{"access_token":"footoken","scope":"read_products,write_orders"}`))
+
+	store := NewMemoryTokenStore()
+	a := app
+	a.Client = client
+	a.TokenStore = store
+
+	token, err := a.RequestAccessToken("fooshop", "foocode")
+	if err != nil {
+		t.Fatalf("App.RequestAccessToken(): %v", err)
+	}
+	if token.Shop != "fooshop.myshopify.com" || token.Token != "footoken" || token.Scope != "read_products,write_orders" || token.CreatedAt.IsZero() {
+		t.Errorf("App.RequestAccessToken() = %#v", token)
+	}
+
+	stored, err := store.Load("fooshop")
+	if err != nil {
+		t.Fatalf("token was not stored: %v", err)
+	}
+	if !reflect.DeepEqual(stored, token) {
+		t.Errorf("stored token %#v, expected %#v", stored, token)
+	}
+}
+
+func TestAppRequestOnlineAccessToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", "https://fooshop.myshopify.com/admin/oauth/access_token",
+		httpmock.NewStringResponder(200, `{
+			"access_token": "onlinetoken",
+			"scope": "read_products,write_orders",
+			"expires_in": 86399,
+			"associated_user_scope": "read_products",
+			"associated_user": {
+				"id": 902541635,
+				"first_name": "John",
+				"last_name": "Smith",
+				"email": "john@example.com",
+				"email_verified": true,
+				"account_owner": true,
+				"locale": "en",
+				"collaborator": false
+			}
+		}`))
+
+	store := NewMemoryTokenStore()
+	a := app
+	a.Client = client
+	a.TokenStore = store
+
+	token, err := a.RequestAccessToken("fooshop", "foocode")
+	if err != nil {
+		t.Fatalf("App.RequestAccessToken(): %v", err)
+	}
+
+	expectedUser := &AssociatedUser{
+		ID:            902541635,
+		FirstName:     "John",
+		LastName:      "Smith",
+		Email:         "john@example.com",
+		EmailVerified: true,
+		AccountOwner:  true,
+		Locale:        "en",
+	}
+	if !reflect.DeepEqual(token.AssociatedUser, expectedUser) {
+		t.Errorf("AssociatedUser = %#v, expected %#v", token.AssociatedUser, expectedUser)
+	}
+	if token.AssociatedUserScope != "read_products" {
+		t.Errorf("AssociatedUserScope = %s, expected read_products", token.AssociatedUserScope)
+	}
+	if !token.Online() || token.Expired() {
+		t.Errorf("Online() = %v, Expired() = %v, expected an online token that has not expired", token.Online(), token.Expired())
+	}
+	if expected := token.CreatedAt.Add(86399 * time.Second); !token.ExpiresAt.Equal(expected) {
+		t.Errorf("ExpiresAt = %v, expected %v", token.ExpiresAt, expected)
+	}
+
+	if _, err := store.Load("fooshop"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("online token was stored: %v", err)
+	}
+}
+
+func TestAppExchangeSessionToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", "https://fooshop.myshopify.com/admin/oauth/access_token",
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			sent := map[string]string{}
+			json.Unmarshal(body, &sent)
+			expected := map[string]string{
+				"client_id":            "apikey",
+				"client_secret":        "hush",
+				"grant_type":           "urn:ietf:params:oauth:grant-type:token-exchange",
+				"subject_token":        "sessiontoken",
+				"subject_token_type":   "urn:ietf:params:oauth:token-type:id_token",
+				"requested_token_type": string(OfflineAccessToken),
+			}
+			if !reflect.DeepEqual(sent, expected) {
+				t.Errorf("App.ExchangeSessionToken() sent %s", body)
+			}
+			return httpmock.NewStringResponse(200, `{"access_token":"footoken","scope":"read_products"}`), nil
+		})
+
+	store := NewMemoryTokenStore()
+	a := app
+	a.Client = client
+	a.TokenStore = store
+
+	token, err := a.ExchangeSessionToken("fooshop.myshopify.com", "sessiontoken", OfflineAccessToken)
+	if err != nil {
+		t.Fatalf("App.ExchangeSessionToken(): %v", err)
+	}
+	if token.Shop != "fooshop.myshopify.com" || token.Token != "footoken" || token.Online() {
+		t.Errorf("App.ExchangeSessionToken() = %#v", token)
+	}
+	if stored, err := store.Load("fooshop"); err != nil || stored.Token != "footoken" {
+		t.Errorf("token was not stored: %v", err)
+	}
+}
+
+func TestAppExchangeSessionTokenError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", "https://fooshop.myshopify.com/admin/oauth/access_token",
+		httpmock.NewStringResponder(400, `{"error":"invalid_subject_token","error_description":"Session token is invalid."}`))
+
+	a := app
+	a.Client = client
+
+	token, err := a.ExchangeSessionToken("fooshop.myshopify.com", "sessiontoken", OnlineAccessToken)
+	if err == nil || err.Error() != "invalid_subject_token" || token != nil {
+		t.Errorf("App.ExchangeSessionToken() = %v, %v, expected invalid_subject_token", token, err)
+	}
+}
+
+func TestAppExchangeSessionTokenWithContext(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("POST", "https://fooshop.myshopify.com/admin/oauth/access_token",
+		httpmock.NewStringResponder(200, `{"access_token":"footoken","scope":"read_products"}`))
+
+	a := app
+	a.Client = client
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	token, err := a.ExchangeSessionTokenWithContext(ctx, "fooshop.myshopify.com", "sessiontoken", OfflineAccessToken)
+	if !errors.Is(err, context.Canceled) || token != nil {
+		t.Errorf("App.ExchangeSessionTokenWithContext() = %v, %v, expected context.Canceled", token, err)
+	}
+	if calls := httpmock.GetCallCountInfo()["POST https://fooshop.myshopify.com/admin/oauth/access_token"]; calls != 0 {
+		t.Errorf("App.ExchangeSessionTokenWithContext() sent %d requests with a cancelled context", calls)
+	}
+}
+
 func TestAppGetAccessTokenError(t *testing.T) {
 	setup()
 	defer teardown()
@@ -3,6 +3,7 @@ package shopify
 import (
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // Option is used to configure client with options
@@ -15,11 +16,14 @@ func WithVersion(apiVersion string) Option {
 		if len(apiVersion) > 0 && (apiVersionRegex.MatchString(apiVersion) || apiVersion == UnstableApiVersion) {
 			pathPrefix = fmt.Sprintf("admin/api/%s", apiVersion)
 		}
-		c.apiVersion = apiVersion
+		c.state.apiVersion = apiVersion
 		c.pathPrefix = pathPrefix
 	}
 }
 
+// WithRetry sets the number of attempts, including the first one, made for a
+// request that is rate limited or answered with 503 Service Unavailable. Use
+// WithRetryPolicy for more control.
 func WithRetry(retries int) Option {
 	return func(c *Client) {
 		c.retries = retries
@@ -38,3 +42,54 @@ func WithHTTPClient(client *http.Client) Option {
 		c.Client = client
 	}
 }
+
+// WithRateLimiter throttles requests with limiter before they are sent, so
+// goroutines sharing the client stay within Shopify's rate limits instead of
+// being answered with 429 Too Many Requests.
+//
+//	client := NewClient(app, "shopname", "token",
+//		WithRateLimiter(NewLeakyBucket(DefaultBucketSize, DefaultLeakRate)))
+func WithRateLimiter(limiter RateLimiter) Option {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// WithRetryPolicy sets the policy deciding which failed requests are sent
+// again and when, replacing the behaviour of WithRetry.
+//
+//	client := NewClient(app, "shopname", "token",
+//		WithRetryPolicy(NewBackoffRetryPolicy(DefaultRetryAttempts)))
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithDeprecationHook sets a function called for every request Shopify
+// reports as deprecated through the X-Shopify-API-Deprecated-Reason header.
+// The client also logs a warning the first time each endpoint is called.
+// See DeprecationCollector.
+func WithDeprecationHook(hook func(DeprecatedCall)) Option {
+	return func(c *Client) {
+		c.deprecationHook = hook
+	}
+}
+
+// WithTokenExpiry makes the client refuse to send requests once expiresAt,
+// the expiry of an online access token, has passed. Requests then fail with
+// ErrTokenExpired instead of being answered with 401 Unauthorized.
+func WithTokenExpiry(expiresAt time.Time) Option {
+	return func(c *Client) {
+		c.tokenExpiry = expiresAt
+	}
+}
+
+// WithUninstallHook sets a function called once when a request fails because
+// the access token was revoked, which happens when the shop uninstalled the
+// app. See ErrTokenRevoked and PurgeOnUninstall.
+func WithUninstallHook(hook UninstallHook) Option {
+	return func(c *Client) {
+		c.uninstallHook = hook
+	}
+}
@@ -72,3 +72,30 @@ func TestWithHTTPClient(t *testing.T) {
 		t.Errorf("WithVersion client.Client = %s, expected %s", c.Client.Timeout, expected)
 	}
 }
+
+func TestWithRateLimiter(t *testing.T) {
+	limiter := NewLeakyBucket(DefaultBucketSize, DefaultLeakRate)
+	c := NewClient(app, "fooshop", "abcd", WithRateLimiter(limiter))
+
+	if c.rateLimiter != limiter {
+		t.Errorf("WithRateLimiter client.rateLimiter = %v, expected %v", c.rateLimiter, limiter)
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	policy := NewBackoffRetryPolicy(DefaultRetryAttempts)
+	c := NewClient(app, "fooshop", "abcd", WithRetryPolicy(policy))
+
+	if c.retryPolicy != policy {
+		t.Errorf("WithRetryPolicy client.retryPolicy = %v, expected %v", c.retryPolicy, policy)
+	}
+}
+
+func TestWithTokenExpiry(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	c := NewClient(app, "fooshop", "abcd", WithTokenExpiry(expiresAt))
+
+	if !c.tokenExpiry.Equal(expiresAt) {
+		t.Errorf("WithTokenExpiry client.tokenExpiry = %v, expected %v", c.tokenExpiry, expiresAt)
+	}
+}
@@ -18,6 +18,7 @@ const ordersResourceName = "orders"
 type OrderService interface {
 	List(interface{}) ([]Order, error)
 	ListWithPagination(interface{}) ([]Order, *Pagination, error)
+	ListAll(interface{}, func([]Order) error) error
 	Count(interface{}) (int, error)
 	Get(int64, interface{}) (*Order, error)
 	Create(Order) (*Order, error)
@@ -417,6 +418,17 @@ func (s *OrderServiceOp) ListWithPagination(options interface{}) ([]Order, *Pagi
 	return resource.Orders, pagination, nil
 }
 
+// ListAll walks every page of orders, calling fn with each page. See Client.ListAll.
+func (s *OrderServiceOp) ListAll(options interface{}, fn func([]Order) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		orders, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(orders)
+	})
+}
+
 // Count orders
 func (s *OrderServiceOp) Count(options interface{}) (int, error) {
 	path := fmt.Sprintf("%s/count.json", ordersBasePath)
@@ -0,0 +1,72 @@
+package shopify
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrStopPagination can be returned, or wrapped, by a ListAll callback to
+// stop walking pages early. ListAll then returns nil.
+var ErrStopPagination = errors.New("stop pagination")
+
+// ListWithPagination performs a GET request for a cursor paginated endpoint,
+// saves the page in the given resource and returns the pagination to retrieve
+// the next/previous page.
+func (c *Client) ListWithPagination(path string, resource, options interface{}) (*Pagination, error) {
+	headers, err := c.createAndDoGetHeaders("GET", path, nil, options, resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return extractPagination(headers.Get("Link"))
+}
+
+// ListAll walks every page of a cursor paginated endpoint. Each page is
+// decoded into resource, which is reset beforehand, and fn is called once per
+// page so results can be streamed instead of held in memory. The page size is
+// controlled through the Limit of the options.
+//
+// Returning ErrStopPagination from fn stops early without an error, any
+// other error stops and is returned.
+//
+//	resource := new(WebhooksResource)
+//	err := client.ListAll("webhooks.json", resource, ListOptions{Limit: 250}, func() error {
+//		for _, webhook := range resource.Webhooks {
+//			...
+//		}
+//		return nil
+//	})
+func (c *Client) ListAll(path string, resource, options interface{}, fn func() error) error {
+	v := reflect.ValueOf(resource)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("resource must be a non-nil pointer")
+	}
+
+	return c.paginate(options, func(options interface{}) (*Pagination, error) {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+		pagination, err := c.ListWithPagination(path, resource, options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn()
+	})
+}
+
+// paginate calls page with the given options and then with the options of
+// every next page until there are no pages left or page returns an error.
+func (c *Client) paginate(options interface{}, page func(options interface{}) (*Pagination, error)) error {
+	for {
+		pagination, err := page(options)
+		if errors.Is(err, ErrStopPagination) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if pagination == nil || pagination.NextPageOptions == nil {
+			return nil
+		}
+		options = pagination.NextPageOptions
+	}
+}
@@ -0,0 +1,144 @@
+package shopify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// registerWebhookPages registers three pages of webhooks, linked through the
+// Link header, and returns the ids in the order they are served.
+func registerWebhookPages() []int64 {
+	basePath := fmt.Sprintf("https://fooshop.myshopify.com/%s/webhooks.json", client.pathPrefix)
+	link := func(pageInfo string) string {
+		return fmt.Sprintf(`<%s?limit=2&page_info=%s>; rel="next"`, basePath, pageInfo)
+	}
+
+	pages := []struct {
+		query map[string]string
+		body  string
+		link  string
+	}{
+		{map[string]string{"limit": "2"}, `{"webhooks":[{"id":1},{"id":2}]}`, link("second")},
+		{map[string]string{"limit": "2", "page_info": "second"}, `{"webhooks":[{"id":3},{"id":4}]}`, link("third")},
+		{map[string]string{"limit": "2", "page_info": "third"}, `{"webhooks":[{"id":5}]}`, ""},
+	}
+
+	for _, p := range pages {
+		headers := http.Header{}
+		if p.link != "" {
+			headers.Add("Link", p.link)
+		}
+		httpmock.RegisterResponderWithQuery("GET", basePath, p.query,
+			httpmock.ResponderFromResponse(&http.Response{
+				StatusCode: 200,
+				Body:       httpmock.NewRespBodyFromString(p.body),
+				Header:     headers,
+			}))
+	}
+
+	return []int64{1, 2, 3, 4, 5}
+}
+
+func TestClientListAll(t *testing.T) {
+	setup()
+	defer teardown()
+
+	expected := registerWebhookPages()
+
+	var ids []int64
+	pages := 0
+	resource := new(WebhooksResource)
+	err := client.ListAll("webhooks.json", resource, ListOptions{Limit: 2}, func() error {
+		pages++
+		for _, webhook := range resource.Webhooks {
+			ids = append(ids, webhook.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Client.ListAll returned error: %v", err)
+	}
+
+	if pages != 3 {
+		t.Errorf("Client.ListAll called fn %d times, expected 3", pages)
+	}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Client.ListAll returned ids %v, expected %v", ids, expected)
+	}
+}
+
+func TestClientListAllStop(t *testing.T) {
+	setup()
+	defer teardown()
+
+	registerWebhookPages()
+
+	pages := 0
+	resource := new(WebhooksResource)
+	err := client.ListAll("webhooks.json", resource, ListOptions{Limit: 2}, func() error {
+		pages++
+		return ErrStopPagination
+	})
+	if err != nil {
+		t.Errorf("Client.ListAll returned error: %v", err)
+	}
+	if pages != 1 {
+		t.Errorf("Client.ListAll called fn %d times, expected 1", pages)
+	}
+
+	// a wrapped sentinel stops too
+	pages = 0
+	err = client.ListAll("webhooks.json", resource, ListOptions{Limit: 2}, func() error {
+		pages++
+		return fmt.Errorf("found it: %w", ErrStopPagination)
+	})
+	if err != nil {
+		t.Errorf("Client.ListAll returned error: %v", err)
+	}
+	if pages != 1 {
+		t.Errorf("Client.ListAll called fn %d times, expected 1", pages)
+	}
+
+	expectedErr := errors.New("callback error")
+	err = client.ListAll("webhooks.json", resource, ListOptions{Limit: 2}, func() error {
+		return expectedErr
+	})
+	if err != expectedErr {
+		t.Errorf("Client.ListAll returned error %v, expected %v", err, expectedErr)
+	}
+}
+
+func TestClientListAllInvalidResource(t *testing.T) {
+	setup()
+	defer teardown()
+
+	err := client.ListAll("webhooks.json", WebhooksResource{}, nil, func() error { return nil })
+	if err == nil {
+		t.Errorf("Client.ListAll expected error for non-pointer resource")
+	}
+}
+
+func TestClientListAllError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/webhooks.json", client.pathPrefix),
+		httpmock.NewStringResponder(404, `{"errors":"Not Found"}`))
+
+	called := false
+	err := client.ListAll("webhooks.json", new(WebhooksResource), nil, func() error {
+		called = true
+		return nil
+	})
+	if err == nil {
+		t.Errorf("Client.ListAll expected error")
+	}
+	if called {
+		t.Errorf("Client.ListAll called fn for a failed page")
+	}
+}
@@ -0,0 +1,301 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPoolIdleTimeout is how long a ClientPool keeps the client of a
+	// shop that is not used.
+	DefaultPoolIdleTimeout = 30 * time.Minute
+	// DefaultPoolConcurrency is the number of shops ClientPool.Each works on
+	// at once when no concurrency is given.
+	DefaultPoolConcurrency = 10
+)
+
+// TokenLookup returns the access token of a shop, see ClientPool.
+type TokenLookup interface {
+	Token(shop string) (string, error)
+}
+
+// TokenLookupFunc is a function implementing TokenLookup.
+type TokenLookupFunc func(shop string) (string, error)
+
+// Token returns f(shop).
+func (f TokenLookupFunc) Token(shop string) (string, error) {
+	return f(shop)
+}
+
+// PoolOption is used to configure a ClientPool.
+type PoolOption func(p *ClientPool)
+
+// WithPoolClientOptions sets options applied to every client of the pool,
+// after the pool's own http client and rate limiter.
+func WithPoolClientOptions(opts ...Option) PoolOption {
+	return func(p *ClientPool) {
+		p.opts = append(p.opts, opts...)
+	}
+}
+
+// WithPoolIdleTimeout sets how long the client of an unused shop is kept,
+// zero keeps clients until they are evicted explicitly.
+func WithPoolIdleTimeout(timeout time.Duration) PoolOption {
+	return func(p *ClientPool) {
+		p.idleTimeout = timeout
+	}
+}
+
+// WithPoolHTTPClient sets the http client shared by every client of the pool.
+func WithPoolHTTPClient(client *http.Client) PoolOption {
+	return func(p *ClientPool) {
+		p.httpClient = client
+	}
+}
+
+// WithPoolRateLimiter sets the function creating the rate limiter of each
+// shop, nil disables client side rate limiting.
+func WithPoolRateLimiter(newLimiter func() RateLimiter) PoolOption {
+	return func(p *ClientPool) {
+		p.newLimiter = newLimiter
+	}
+}
+
+// ClientPool manages the clients of many shops. Clients are built lazily from
+// the pool's App and the token returned by a TokenLookup, share one http
+// client and its connections, and each get a LeakyBucket so the shop's rate
+// limit is respected across goroutines. Clients of shops that were not used
+// for the idle timeout are evicted. It is safe for concurrent use.
+//
+//	pool := shopify.NewClientPool(app, tokens)
+//	client, err := pool.Get("theshop.myshopify.com")
+type ClientPool struct {
+	app         App
+	tokens      TokenLookup
+	opts        []Option
+	httpClient  *http.Client
+	newLimiter  func() RateLimiter
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	clients   map[string]*pooledClient
+	lastSweep time.Time
+}
+
+type pooledClient struct {
+	client   *Client
+	lastUsed time.Time
+}
+
+// NewClientPool returns a ClientPool building clients for app with the
+// tokens returned by tokens.
+func NewClientPool(app App, tokens TokenLookup, opts ...PoolOption) *ClientPool {
+	p := &ClientPool{
+		app:    app,
+		tokens: tokens,
+		httpClient: &http.Client{
+			Timeout:   time.Second * defaultHttpTimeout,
+			Transport: newPoolTransport(),
+		},
+		newLimiter: func() RateLimiter {
+			return NewLeakyBucket(DefaultBucketSize, DefaultLeakRate)
+		},
+		idleTimeout: DefaultPoolIdleTimeout,
+		clients:     map[string]*pooledClient{},
+		lastSweep:   time.Now(),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// newPoolTransport returns a transport keeping enough idle connections for
+// many shops, which are all different hosts.
+func newPoolTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 1000
+	transport.MaxIdleConnsPerHost = 10
+	return transport
+}
+
+// Get returns the client of shop, building it when the pool holds none.
+func (p *ClientPool) Get(shop string) (*Client, error) {
+	key := shopKey(shop)
+	now := time.Now()
+
+	p.mu.Lock()
+	p.sweep(now)
+	if pooled, ok := p.clients[key]; ok {
+		pooled.lastUsed = now
+		p.mu.Unlock()
+		return pooled.client, nil
+	}
+	p.mu.Unlock()
+
+	// look the token up without holding the lock, it may be slow
+	token, err := p.tokens.Token(key)
+	if err != nil {
+		return nil, fmt.Errorf("shopify: looking up token of %s: %w", key, err)
+	}
+
+	opts := []Option{WithHTTPClient(p.httpClient)}
+	if p.newLimiter != nil {
+		opts = append(opts, WithRateLimiter(p.newLimiter()))
+	}
+	opts = append(opts, p.opts...)
+	client := NewClient(p.app, key, token, opts...)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// another goroutine may have built the client meanwhile, keep the first
+	// one so the shop has a single rate limiter
+	if pooled, ok := p.clients[key]; ok {
+		pooled.lastUsed = now
+		return pooled.client, nil
+	}
+	p.clients[key] = &pooledClient{client: client, lastUsed: now}
+	return client, nil
+}
+
+// Evict drops the client of shop, e.g. when its token changed or the app was
+// uninstalled. The next Get builds a new one.
+func (p *ClientPool) Evict(shop string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, shopKey(shop))
+}
+
+// EvictIdle drops the clients of shops not used for the idle timeout and
+// returns how many were dropped. Get evicts idle clients as well, calling
+// EvictIdle is only needed to free memory when the pool is not used.
+func (p *ClientPool) EvictIdle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastSweep = time.Time{}
+	return p.sweep(time.Now())
+}
+
+// Shops returns the shops the pool holds a client for, sorted.
+func (p *ClientPool) Shops() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	shops := make([]string, 0, len(p.clients))
+	for shop := range p.clients {
+		shops = append(shops, shop)
+	}
+	sort.Strings(shops)
+	return shops
+}
+
+// Len returns the number of clients the pool holds.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.clients)
+}
+
+// sweep drops idle clients at most once every half idle timeout, p.mu must
+// be held.
+func (p *ClientPool) sweep(now time.Time) int {
+	if p.idleTimeout <= 0 || now.Sub(p.lastSweep) < p.idleTimeout/2 {
+		return 0
+	}
+	p.lastSweep = now
+
+	evicted := 0
+	for shop, pooled := range p.clients {
+		if now.Sub(pooled.lastUsed) >= p.idleTimeout {
+			delete(p.clients, shop)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// PoolErrors is returned by ClientPool.Each, mapping each shop that failed to
+// its error.
+type PoolErrors map[string]error
+
+func (e PoolErrors) Error() string {
+	shops := make([]string, 0, len(e))
+	for shop := range e {
+		shops = append(shops, shop)
+	}
+	sort.Strings(shops)
+
+	messages := make([]string, 0, len(shops))
+	for _, shop := range shops {
+		messages = append(messages, fmt.Sprintf("%s: %v", shop, e[shop]))
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Each calls fn for every shop with its client, bound to ctx, working on at
+// most concurrency shops at once. Shops are skipped once ctx is done. It
+// returns PoolErrors holding the shops whose client could not be built or
+// for which fn failed, nil if none did.
+//
+//	err := pool.Each(ctx, shops, 5, func(shop string, client *shopify.Client) error {
+//		count, err := client.Order.Count(nil)
+//		...
+//	})
+func (p *ClientPool) Each(ctx context.Context, shops []string, concurrency int, fn func(shop string, client *Client) error) error {
+	if concurrency <= 0 {
+		concurrency = DefaultPoolConcurrency
+	}
+
+	var mu sync.Mutex
+	errs := PoolErrors{}
+	fail := func(shop string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs[shop] = err
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, shop := range shops {
+		shop := shopKey(shop)
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			fail(shop, ctx.Err())
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := ctx.Err(); err != nil {
+				fail(shop, err)
+				return
+			}
+
+			client, err := p.Get(shop)
+			if err != nil {
+				fail(shop, err)
+				return
+			}
+			if err := fn(shop, client.WithContext(ctx)); err != nil {
+				fail(shop, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
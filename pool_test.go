@@ -0,0 +1,190 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func newTestPool(t *testing.T, opts ...PoolOption) (*ClientPool, *int32) {
+	var lookups int32
+	tokens := TokenLookupFunc(func(shop string) (string, error) {
+		atomic.AddInt32(&lookups, 1)
+		if shop == "unknown.myshopify.com" {
+			return "", errors.New("no token")
+		}
+		return "token-" + shop, nil
+	})
+
+	httpClient := &http.Client{}
+	httpmock.ActivateNonDefault(httpClient)
+	t.Cleanup(httpmock.DeactivateAndReset)
+
+	opts = append([]PoolOption{WithPoolHTTPClient(httpClient), WithPoolClientOptions(WithVersion(testApiVersion))}, opts...)
+	return NewClientPool(app, tokens, opts...), &lookups
+}
+
+func TestClientPoolGet(t *testing.T) {
+	pool, lookups := newTestPool(t)
+
+	c1, err := pool.Get("fooshop")
+	if err != nil {
+		t.Fatalf("ClientPool.Get returned error: %v", err)
+	}
+	c2, err := pool.Get("FooShop.myshopify.com")
+	if err != nil {
+		t.Fatalf("ClientPool.Get returned error: %v", err)
+	}
+	if c1 != c2 {
+		t.Error("ClientPool.Get built a second client for the same shop")
+	}
+	if *lookups != 1 {
+		t.Errorf("ClientPool.Get looked the token up %d times, expected 1", *lookups)
+	}
+
+	if c1.token != "token-fooshop.myshopify.com" {
+		t.Errorf("client token = %q", c1.token)
+	}
+	if c1.baseURL.String() != "https://fooshop.myshopify.com" {
+		t.Errorf("client baseURL = %s", c1.baseURL)
+	}
+	if c1.pathPrefix != fmt.Sprintf("admin/api/%s", testApiVersion) {
+		t.Errorf("client options were not applied, pathPrefix = %s", c1.pathPrefix)
+	}
+	if c1.rateLimiter == nil {
+		t.Error("client has no rate limiter")
+	}
+
+	c3, err := pool.Get("barshop")
+	if err != nil {
+		t.Fatalf("ClientPool.Get returned error: %v", err)
+	}
+	if c3.Client != c1.Client {
+		t.Error("clients do not share the http client")
+	}
+	if c3.rateLimiter == c1.rateLimiter {
+		t.Error("clients share a rate limiter")
+	}
+
+	if _, err := pool.Get("unknown"); err == nil {
+		t.Error("ClientPool.Get: expected an error for a shop without token")
+	}
+
+	expectedShops := []string{"barshop.myshopify.com", "fooshop.myshopify.com"}
+	if shops := pool.Shops(); fmt.Sprint(shops) != fmt.Sprint(expectedShops) {
+		t.Errorf("ClientPool.Shops() = %v, expected %v", shops, expectedShops)
+	}
+
+	pool.Evict("fooshop")
+	c4, _ := pool.Get("fooshop")
+	if c4 == c1 || *lookups != 4 {
+		t.Errorf("ClientPool.Evict did not drop the client, lookups = %d", *lookups)
+	}
+}
+
+func TestClientPoolEvictIdle(t *testing.T) {
+	pool, _ := newTestPool(t, WithPoolIdleTimeout(time.Hour))
+
+	pool.Get("fooshop")
+	pool.Get("barshop")
+
+	pool.mu.Lock()
+	pool.clients["fooshop.myshopify.com"].lastUsed = time.Now().Add(-2 * time.Hour)
+	pool.mu.Unlock()
+
+	if evicted := pool.EvictIdle(); evicted != 1 {
+		t.Errorf("ClientPool.EvictIdle() = %d, expected 1", evicted)
+	}
+	if shops := pool.Shops(); len(shops) != 1 || shops[0] != "barshop.myshopify.com" {
+		t.Errorf("ClientPool.Shops() = %v", shops)
+	}
+
+	// Get sweeps idle clients too
+	pool.mu.Lock()
+	pool.clients["barshop.myshopify.com"].lastUsed = time.Now().Add(-2 * time.Hour)
+	pool.lastSweep = time.Now().Add(-time.Hour)
+	pool.mu.Unlock()
+
+	pool.Get("bazshop")
+	if shops := pool.Shops(); len(shops) != 1 || shops[0] != "bazshop.myshopify.com" {
+		t.Errorf("ClientPool.Shops() = %v", shops)
+	}
+}
+
+func TestClientPoolEach(t *testing.T) {
+	pool, _ := newTestPool(t)
+
+	shops := []string{"shop1", "shop2", "shop3", "shop4", "shop5", "unknown"}
+	for _, shop := range shops {
+		httpmock.RegisterResponder("GET", fmt.Sprintf("https://%s.myshopify.com/admin/api/%s/shop.json", shop, testApiVersion),
+			httpmock.NewStringResponder(200, fmt.Sprintf(`{"shop": {"myshopify_domain": "%s.myshopify.com"}}`, shop)))
+	}
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	seen := map[string]bool{}
+
+	err := pool.Each(context.Background(), shops, 2, func(shop string, client *Client) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		s, err := client.Shop.Get(nil)
+		if err != nil {
+			return err
+		}
+		if s.MyshopifyDomain != shop {
+			return fmt.Errorf("client of %s fetched %s", shop, s.MyshopifyDomain)
+		}
+
+		mu.Lock()
+		seen[shop] = true
+		mu.Unlock()
+		if shop == "shop3.myshopify.com" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	var poolErrs PoolErrors
+	if !errors.As(err, &poolErrs) {
+		t.Fatalf("ClientPool.Each returned %#v, expected PoolErrors", err)
+	}
+	if len(poolErrs) != 2 || poolErrs["shop3.myshopify.com"] == nil || poolErrs["unknown.myshopify.com"] == nil {
+		t.Errorf("ClientPool.Each returned errors %v", poolErrs)
+	}
+	if len(seen) != 5 {
+		t.Errorf("ClientPool.Each called fn for %v", seen)
+	}
+	if maxRunning > 2 {
+		t.Errorf("ClientPool.Each ran %d shops at once, expected at most 2", maxRunning)
+	}
+
+	// a done context skips the shops
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = pool.Each(ctx, shops[:2], 1, func(shop string, client *Client) error {
+		t.Errorf("ClientPool.Each called fn for %s with a done context", shop)
+		return nil
+	})
+	if !errors.As(err, &poolErrs) || !errors.Is(poolErrs["shop1.myshopify.com"], context.Canceled) {
+		t.Errorf("ClientPool.Each returned %v, expected context errors", err)
+	}
+}
@@ -22,6 +22,7 @@ var linkRegex = regexp.MustCompile(`^ *<([^>]+)>; rel="(previous|next)" *$`)
 type ProductService interface {
 	List(interface{}) ([]Product, error)
 	ListWithPagination(interface{}) ([]Product, *Pagination, error)
+	ListAll(interface{}, func([]Product) error) error
 	Count(interface{}) (int, error)
 	Get(int64, interface{}) (*Product, error)
 	Create(Product) (*Product, error)
@@ -130,6 +131,17 @@ func (s *ProductServiceOp) ListWithPagination(options interface{}) ([]Product, *
 	return resource.Products, pagination, nil
 }
 
+// ListAll walks every page of products, calling fn with each page. See Client.ListAll.
+func (s *ProductServiceOp) ListAll(options interface{}, fn func([]Product) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		products, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(products)
+	})
+}
+
 // extractPagination extracts pagination info from linkHeader.
 // Details on the format are here:
 // https://help.shopify.com/en/api/guides/paginated-rest-results
@@ -176,6 +188,8 @@ func extractPagination(linkHeader string) (*Pagination, error) {
 			return nil, err
 		}
 
+		paginationListOptions.Fields = params.Get("fields")
+
 		limit := params.Get("limit")
 		if limit != "" {
 			paginationListOptions.Limit, err = strconv.Atoi(params.Get("limit"))
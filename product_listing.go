@@ -15,6 +15,7 @@ const productsListingResourceName = "product_listings"
 type ProductListingService interface {
 	List(interface{}) ([]ProductListing, error)
 	ListWithPagination(interface{}) ([]ProductListing, *Pagination, error)
+	ListAll(interface{}, func([]ProductListing) error) error
 	Count(interface{}) (int, error)
 	Get(int64, interface{}) (*ProductListing, error)
 	GetProductIDs(interface{}) ([]int64, error)
@@ -105,6 +106,17 @@ func (s *ProductListingServiceOp) ListWithPagination(options interface{}) ([]Pro
 	return resource.ProductListings, pagination, nil
 }
 
+// ListAll walks every page of product listings, calling fn with each page. See Client.ListAll.
+func (s *ProductListingServiceOp) ListAll(options interface{}, fn func([]ProductListing) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		listings, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(listings)
+	})
+}
+
 // Count products listings published to your sales channel app
 func (s *ProductListingServiceOp) Count(options interface{}) (int, error) {
 	path := fmt.Sprintf("%s/count.json", productListingBasePath)
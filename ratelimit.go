@@ -0,0 +1,129 @@
+package shopify
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBucketSize is the REST API bucket size of a standard Shopify plan.
+	DefaultBucketSize = 40
+	// DefaultLeakRate is the number of requests per second a standard Shopify
+	// plan's bucket leaks.
+	DefaultLeakRate = 2
+)
+
+// RateLimiter throttles outgoing requests before they are sent to Shopify.
+// See WithRateLimiter.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent or ctx is done.
+	Wait(ctx context.Context) error
+	// Update resyncs the limiter with the limits reported by Shopify.
+	Update(info RateLimitInfo)
+}
+
+// LeakyBucket is a RateLimiter modelling Shopify's leaky bucket algorithm.
+// Every request adds one to the bucket and the bucket leaks at a fixed rate;
+// requests that would overflow the bucket block until enough has leaked.
+//
+// Shopify keeps one bucket per shop and app, so a LeakyBucket must only be
+// shared between clients talking to the same shop. It is safe for concurrent
+// use.
+type LeakyBucket struct {
+	mu       sync.Mutex
+	size     float64
+	leakRate float64
+	level    float64
+	last     time.Time
+}
+
+// NewLeakyBucket returns a LeakyBucket holding size requests and leaking
+// leakRate requests per second. Use DefaultBucketSize and DefaultLeakRate for
+// a standard plan, Shopify Plus shops have twice the leak rate and size. A
+// size below 1 or a leakRate that is not positive can never let a request
+// through and is replaced by the default.
+func NewLeakyBucket(size int, leakRate float64) *LeakyBucket {
+	if size < 1 {
+		size = DefaultBucketSize
+	}
+	// !(leakRate > 0) also catches NaN
+	if !(leakRate > 0) || math.IsInf(leakRate, 0) {
+		leakRate = DefaultLeakRate
+	}
+	return &LeakyBucket{
+		size:     float64(size),
+		leakRate: leakRate,
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until the bucket has room for one more request, then reserves
+// it.
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.leak()
+		if b.level+1 <= b.size {
+			b.level++
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((b.level + 1 - b.size) / b.leakRate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// Update resyncs the bucket with the X-Shopify-Shop-Api-Call-Limit header.
+// The fuller of the local and the reported bucket wins, since Shopify also
+// counts requests made by other processes using the same token. A Retry-After
+// means the bucket is full.
+func (b *LeakyBucket) Update(info RateLimitInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.leak()
+	if info.BucketSize > 0 {
+		b.size = float64(info.BucketSize)
+		b.level = math.Max(b.level, float64(info.RequestCount))
+	}
+	if info.RetryAfterSeconds > 0 {
+		b.level = b.size + info.RetryAfterSeconds*b.leakRate - 1
+	}
+}
+
+// Level returns the number of requests currently in the bucket.
+func (b *LeakyBucket) Level() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.leak()
+	return b.level
+}
+
+// leak drains the bucket for the time passed since the last call, b.mu must
+// be held.
+func (b *LeakyBucket) leak() {
+	now := time.Now()
+	b.level = math.Max(0, b.level-now.Sub(b.last).Seconds()*b.leakRate)
+	b.last = now
+}
+
+// parseRateLimits reads the rate limit headers of a Shopify response.
+func parseRateLimits(header http.Header) RateLimitInfo {
+	info := RateLimitInfo{}
+	if s := strings.Split(header.Get("X-Shopify-Shop-Api-Call-Limit"), "/"); len(s) == 2 {
+		info.RequestCount, _ = strconv.Atoi(s[0])
+		info.BucketSize, _ = strconv.Atoi(s[1])
+	}
+	info.RetryAfterSeconds, _ = strconv.ParseFloat(header.Get("Retry-After"), 64)
+	return info
+}
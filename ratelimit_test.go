@@ -0,0 +1,152 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestLeakyBucketWait(t *testing.T) {
+	bucket := NewLeakyBucket(2, 20)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := bucket.Wait(context.Background()); err != nil {
+			t.Fatalf("LeakyBucket.Wait returned error: %v", err)
+		}
+	}
+
+	// the third request has to wait for one to leak, 1/20th of a second
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("LeakyBucket.Wait did not block when full, took %s", elapsed)
+	}
+}
+
+func TestLeakyBucketWaitContext(t *testing.T) {
+	bucket := NewLeakyBucket(1, 0.1)
+	if err := bucket.Wait(context.Background()); err != nil {
+		t.Fatalf("LeakyBucket.Wait returned error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := bucket.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LeakyBucket.Wait returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestNewLeakyBucketInvalid(t *testing.T) {
+	cases := []struct {
+		size     int
+		leakRate float64
+	}{
+		{0, DefaultLeakRate},
+		{-1, DefaultLeakRate},
+		{DefaultBucketSize, 0},
+		{DefaultBucketSize, -2},
+		{DefaultBucketSize, math.NaN()},
+		{DefaultBucketSize, math.Inf(1)},
+	}
+
+	for _, c := range cases {
+		bucket := NewLeakyBucket(c.size, c.leakRate)
+		if bucket.size != DefaultBucketSize || bucket.leakRate != DefaultLeakRate {
+			t.Errorf("NewLeakyBucket(%d, %v) has size %v and leak rate %v, expected the defaults", c.size, c.leakRate, bucket.size, bucket.leakRate)
+		}
+	}
+
+	// a full bucket waits for the default leak rate rather than spinning
+	bucket := NewLeakyBucket(1, 0)
+	bucket.Update(RateLimitInfo{RequestCount: 1, BucketSize: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bucket.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LeakyBucket.Wait returned %v, expected %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestLeakyBucketUpdate(t *testing.T) {
+	cases := []struct {
+		description string
+		info        RateLimitInfo
+		min         float64
+		max         float64
+	}{
+		{
+			"reported level above local level is adopted",
+			RateLimitInfo{RequestCount: 30, BucketSize: 40},
+			29.9, 30,
+		},
+		{
+			"reported level below local level is ignored",
+			RateLimitInfo{RequestCount: 0, BucketSize: 40},
+			4.9, 5,
+		},
+		{
+			"retry after fills the bucket",
+			RateLimitInfo{RetryAfterSeconds: 2},
+			42.9, 43,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			bucket := NewLeakyBucket(DefaultBucketSize, DefaultLeakRate)
+			for i := 0; i < 5; i++ {
+				bucket.Wait(context.Background())
+			}
+
+			bucket.Update(c.info)
+			if level := bucket.Level(); level < c.min || level > c.max {
+				t.Errorf("LeakyBucket.Level() = %v, expected between %v and %v", level, c.min, c.max)
+			}
+		})
+	}
+}
+
+func TestLeakyBucketConcurrent(t *testing.T) {
+	bucket := NewLeakyBucket(10, 1000)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bucket.Wait(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if level := bucket.Level(); level > 10 {
+		t.Errorf("LeakyBucket.Level() = %v, overflowed bucket of 10", level)
+	}
+}
+
+func TestClientRateLimiterResync(t *testing.T) {
+	setup()
+	defer teardown()
+
+	bucket := NewLeakyBucket(DefaultBucketSize, DefaultLeakRate)
+	WithRateLimiter(bucket)(client)
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/foo/1", client.pathPrefix),
+		createResponderWithHeaders(200, `{"foo": "bar"}`, map[string]string{
+			"X-Shopify-Shop-Api-Call-Limit": "39/40",
+		}))
+
+	if err := client.Get("foo/1", nil, nil); err != nil {
+		t.Fatalf("Client.Get returned error: %v", err)
+	}
+
+	if level := bucket.Level(); level < 38.9 {
+		t.Errorf("LeakyBucket.Level() = %v, expected the client to resync it to 39", level)
+	}
+}
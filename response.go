@@ -0,0 +1,134 @@
+package shopify
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Response holds the metadata of Shopify's response to a request, see
+// Client.WithResponse. When a request fails it describes the last attempt.
+type Response struct {
+	// Status and Header of the response, zero when no response was received.
+	Status int
+	Header http.Header
+
+	// RequestID is the X-Request-Id of the response, Shopify support asks
+	// for it when investigating a request.
+	RequestID string
+
+	// APIVersion is the api version Shopify served the request with, it
+	// differs from the requested one when that is not supported anymore.
+	APIVersion string
+
+	// DeprecatedReason is set when the request used a deprecated endpoint or
+	// field, from the X-Shopify-API-Deprecated-Reason header.
+	DeprecatedReason string
+
+	// Attempts is the number of times the request was sent, see WithRetry.
+	Attempts int
+
+	// RateLimits reported by the response.
+	RateLimits RateLimitInfo
+
+	// Pagination to retrieve the next/previous page of a cursor paginated
+	// endpoint, nil if the response has no valid Link header.
+	Pagination *Pagination
+}
+
+// Deprecated reports whether the request used deprecated functionality
+// that will be removed in a future api version.
+func (r Response) Deprecated() bool {
+	return r.DeprecatedReason != ""
+}
+
+// setResponse records the metadata of resp.
+func (r *Response) setResponse(resp *http.Response) {
+	r.Status = resp.StatusCode
+	r.Header = resp.Header
+	r.RequestID = resp.Header.Get("X-Request-Id")
+	r.APIVersion = resp.Header.Get("X-Shopify-API-Version")
+	r.DeprecatedReason = resp.Header.Get("X-Shopify-API-Deprecated-Reason")
+	r.RateLimits = parseRateLimits(resp.Header)
+
+	r.Pagination = nil
+	if link := resp.Header.Get("Link"); link != "" {
+		// a malformed header only matters to callers paginating, which get
+		// the error from ListWithPagination
+		r.Pagination, _ = extractPagination(link)
+	}
+}
+
+// DoWithResponse is like Do but also returns the metadata of the response,
+// which is set when the request failed with a response error as well.
+func (c *Client) DoWithResponse(req *http.Request, v interface{}) (*Response, error) {
+	return c.doResponse(req, v)
+}
+
+// clientState is the state shared by a client and its copies that requests
+// update, it is guarded by mu so the client can be used concurrently.
+type clientState struct {
+	mu sync.Mutex
+
+	// api version the client uses, resolved on the first response when it is
+	// "stable"
+	apiVersion string
+
+	// deprecated endpoints already logged, see reportDeprecation
+	deprecations map[string]bool
+
+	// whether the uninstall hook was called, see reportUninstall
+	uninstalled bool
+
+	// rate limits of the last successful response, see LastRateLimits
+	rateLimits RateLimitInfo
+}
+
+// WithResponse returns a shallow copy of c recording the metadata of every
+// response it receives, including those made through its services, into
+// resp. resp holds the last response, so a copy should not be shared between
+// goroutines.
+//
+//	var resp shopify.Response
+//	products, err := client.WithResponse(&resp).Product.List(nil)
+//	log.Println(resp.RateLimits.RequestCount)
+func (c *Client) WithResponse(resp *Response) *Client {
+	c2 := new(Client)
+	*c2 = *c
+	c2.response = resp
+	c2.initServices()
+	return c2
+}
+
+// APIVersion returns the api version the client uses. A client without an
+// explicit version reports "stable" until the first response tells which
+// version Shopify serves.
+func (c *Client) APIVersion() string {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return c.state.apiVersion
+}
+
+// updateState records the api version and rate limits of a successful
+// response.
+func (c *Client) updateState(header http.Header) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	if c.state.apiVersion == defaultApiVersion && header.Get("X-Shopify-API-Version") != "" {
+		// if using stable on first request set the api version
+		c.state.apiVersion = header.Get("X-Shopify-API-Version")
+		c.log.Infof("api version not set, now using %s", c.state.apiVersion)
+	}
+
+	c.state.rateLimits = parseRateLimits(header)
+}
+
+// LastRateLimits returns the rate limits reported by the last successful
+// response to the client or any of its copies. With concurrent requests it
+// is not known which one that is, use WithResponse for the limits of a given
+// request.
+func (c *Client) LastRateLimits() RateLimitInfo {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	return c.state.rateLimits
+}
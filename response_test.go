@@ -0,0 +1,214 @@
+package shopify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestWithResponse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/shop.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusOK, `{"shop": {"id": 1}}`)
+			resp.Header.Set("X-Shopify-Shop-Api-Call-Limit", "3/40")
+			return resp, nil
+		})
+
+	var resp Response
+	c := client.WithResponse(&resp)
+	if c == client {
+		t.Fatal("WithResponse(): expected a copy of the client")
+	}
+
+	shop, err := c.Shop.Get(nil)
+	if err != nil {
+		t.Fatalf("Shop.Get returned error: %v", err)
+	}
+	if shop.ID != 1 {
+		t.Errorf("Shop.Get returned %#v", shop)
+	}
+
+	expectedLimits := RateLimitInfo{RequestCount: 3, BucketSize: 40}
+	if resp.Status != http.StatusOK || resp.Attempts != 1 || resp.RateLimits != expectedLimits {
+		t.Errorf("WithResponse(): unexpected response %#v", resp)
+	}
+	if resp.Header.Get("X-Shopify-Shop-Api-Call-Limit") != "3/40" {
+		t.Errorf("WithResponse(): header = %v", resp.Header)
+	}
+
+	// the original client does not record responses
+	var other Response
+	client.WithResponse(&other)
+	if _, err := client.Shop.Get(nil); err != nil {
+		t.Fatalf("Shop.Get returned error: %v", err)
+	}
+	if other.Attempts != 0 {
+		t.Errorf("WithResponse(): recorded a response of the original client %#v", other)
+	}
+}
+
+func TestClientConcurrentUse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient := NewClient(app, "fooshop", "abcd", WithRetry(maxRetries))
+	httpmock.ActivateNonDefault(testClient.Client)
+
+	// the first attempt of every caller is answered with 503
+	attempts := map[string]int{}
+	var mu sync.Mutex
+	httpmock.RegisterResponder("GET", "https://fooshop.myshopify.com/admin/shop.json",
+		func(req *http.Request) (*http.Response, error) {
+			caller := req.URL.Query().Get("caller")
+			mu.Lock()
+			attempts[caller]++
+			unavailable := attempts[caller] == 1
+			mu.Unlock()
+
+			if unavailable {
+				return httpmock.NewStringResponse(http.StatusServiceUnavailable, ""), nil
+			}
+			resp := httpmock.NewStringResponse(http.StatusOK, `{"shop": {"id": 1}}`)
+			resp.Header.Set("X-Shopify-API-Version", testApiVersion)
+			return resp, nil
+		})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			options := struct {
+				Caller int `url:"caller"`
+			}{i}
+
+			var resp Response
+			if _, err := testClient.WithResponse(&resp).Shop.Get(options); err != nil {
+				t.Errorf("Shop.Get returned error: %v", err)
+			}
+			if resp.Attempts != 2 {
+				t.Errorf("Shop.Get attempts = %d, expected 2", resp.Attempts)
+			}
+			testClient.APIVersion()
+		}(i)
+	}
+	wg.Wait()
+
+	if testClient.APIVersion() != testApiVersion {
+		t.Errorf("APIVersion() = %s, expected %s", testClient.APIVersion(), testApiVersion)
+	}
+}
+
+func TestClientConcurrentCopies(t *testing.T) {
+	setup()
+	defer teardown()
+
+	testClient := NewClient(app, "fooshop", "abcd", WithVersion(testApiVersion))
+	httpmock.ActivateNonDefault(testClient.Client)
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/shop.json", testClient.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusOK, `{"shop": {"id": 1}}`)
+			resp.Header.Set("X-Shopify-Shop-Api-Call-Limit", "5/40")
+			return resp, nil
+		})
+
+	// requests on the client race with copies of it being made
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			c := testClient
+			switch i % 3 {
+			case 1:
+				c = testClient.WithContext(context.Background())
+			case 2:
+				c = testClient.WithResponse(new(Response))
+			}
+			if _, err := c.Shop.Get(nil); err != nil {
+				t.Errorf("Shop.Get returned error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if limits := testClient.LastRateLimits(); limits.RequestCount != 5 || limits.BucketSize != 40 {
+		t.Errorf("LastRateLimits() = %#v", limits)
+	}
+}
+
+func TestDoWithResponse(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/products.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusOK, `{"products": [{"id": 1}]}`)
+			resp.Header.Set("X-Request-Id", "a1b2c3")
+			resp.Header.Set("X-Shopify-API-Version", "2021-01")
+			resp.Header.Set("X-Shopify-API-Deprecated-Reason", "https://shopify.dev/changelog/deprecated-field")
+			resp.Header.Set("X-Shopify-Shop-Api-Call-Limit", "5/40")
+			resp.Header.Set("Link", `<https://fooshop.myshopify.com/admin/products.json?page_info=abc&limit=1>; rel="next"`)
+			return resp, nil
+		})
+
+	req, err := client.NewRequest("GET", client.pathPrefix+"/products.json", nil, nil)
+	if err != nil {
+		t.Fatalf("NewRequest returned error: %v", err)
+	}
+
+	products := new(ProductsResource)
+	resp, err := client.DoWithResponse(req, products)
+	if err != nil {
+		t.Fatalf("DoWithResponse returned error: %v", err)
+	}
+	if len(products.Products) != 1 {
+		t.Errorf("DoWithResponse decoded %#v", products)
+	}
+
+	if resp.Status != http.StatusOK {
+		t.Errorf("Response.Status = %d, expected 200", resp.Status)
+	}
+	if resp.RequestID != "a1b2c3" {
+		t.Errorf("Response.RequestID = %q, expected a1b2c3", resp.RequestID)
+	}
+	if resp.APIVersion != "2021-01" {
+		t.Errorf("Response.APIVersion = %q, expected 2021-01", resp.APIVersion)
+	}
+	if !resp.Deprecated() || resp.DeprecatedReason != "https://shopify.dev/changelog/deprecated-field" {
+		t.Errorf("Response.DeprecatedReason = %q", resp.DeprecatedReason)
+	}
+	if resp.RateLimits.RequestCount != 5 {
+		t.Errorf("Response.RateLimits = %#v", resp.RateLimits)
+	}
+	if resp.Pagination == nil || resp.Pagination.NextPageOptions == nil || resp.Pagination.NextPageOptions.PageInfo != "abc" {
+		t.Errorf("Response.Pagination = %#v", resp.Pagination)
+	}
+
+	// failed requests report their response too
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/products/2.json", client.pathPrefix),
+		func(req *http.Request) (*http.Response, error) {
+			resp := httpmock.NewStringResponse(http.StatusNotFound, `{"errors": "Not Found"}`)
+			resp.Header.Set("X-Request-Id", "d4e5f6")
+			return resp, nil
+		})
+
+	req, _ = client.NewRequest("GET", client.pathPrefix+"/products/2.json", nil, nil)
+	resp, err = client.DoWithResponse(req, nil)
+	if err == nil {
+		t.Fatal("DoWithResponse: expected an error")
+	}
+	if resp.Status != http.StatusNotFound || resp.RequestID != "d4e5f6" || resp.Pagination != nil || resp.Deprecated() {
+		t.Errorf("DoWithResponse: unexpected response %#v", resp)
+	}
+}
@@ -0,0 +1,212 @@
+package shopify
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultRetryAttempts is the number of attempts, including the first
+	// one, made by a BackoffRetryPolicy.
+	DefaultRetryAttempts = 4
+	// DefaultRetryMinBackoff is the wait before the first retry.
+	DefaultRetryMinBackoff = 500 * time.Millisecond
+	// DefaultRetryMaxBackoff caps the wait between two attempts.
+	DefaultRetryMaxBackoff = 30 * time.Second
+	// DefaultRetryMaxElapsed caps the time spent on a request, all attempts
+	// and waits included.
+	DefaultRetryMaxElapsed = 2 * time.Minute
+	// DefaultRetryJitter is the fraction of each wait that is randomized.
+	DefaultRetryJitter = 0.5
+)
+
+// RetryPolicy decides whether a failed request is sent again. See
+// WithRetryPolicy.
+type RetryPolicy interface {
+	// Retry is called after attempt, counting from 1, of req failed with err,
+	// either a transport error, a response error such as RateLimitError or
+	// ServerError, or GraphQLErrors for a throttled GraphQL query. elapsed is
+	// the time since the first attempt was sent. It returns whether to retry
+	// and how long to wait before doing so.
+	Retry(req *http.Request, err error, attempt int, elapsed time.Duration) (time.Duration, bool)
+}
+
+// BackoffRetryPolicy is a RetryPolicy retrying rate limited requests, 500,
+// 502, 503 and 504 responses, timeouts and other transport errors with
+// exponential backoff.
+//
+// Rate limited requests and throttled GraphQL queries were not processed by
+// Shopify and are retried whatever their method. Rate limited requests wait
+// for at least the Retry-After delay. Throttled GraphQL queries carry no such
+// delay, GraphQLService.Query waits for the backoff or until the query cost
+// is restored, whichever is longer. Other failures are only retried for
+// idempotent requests, so creating a resource is not replayed when Shopify
+// may have processed it already.
+type BackoffRetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry, it doubles with every
+	// further attempt up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxElapsed stops retrying once the next attempt would start after it,
+	// zero for no limit.
+	MaxElapsed time.Duration
+	// Jitter is the fraction, between 0 and 1, of each wait that is
+	// randomized so clients failing together do not retry together.
+	Jitter float64
+	// Idempotent reports whether req may be sent again after a failure that
+	// Shopify may have processed it despite, defaults to IsIdempotent.
+	Idempotent func(req *http.Request) bool
+}
+
+// NewBackoffRetryPolicy returns a BackoffRetryPolicy making at most
+// maxAttempts attempts, using the default backoff.
+func NewBackoffRetryPolicy(maxAttempts int) *BackoffRetryPolicy {
+	return &BackoffRetryPolicy{
+		MaxAttempts: maxAttempts,
+		MinBackoff:  DefaultRetryMinBackoff,
+		MaxBackoff:  DefaultRetryMaxBackoff,
+		MaxElapsed:  DefaultRetryMaxElapsed,
+		Jitter:      DefaultRetryJitter,
+	}
+}
+
+// Retry implements RetryPolicy.
+func (p *BackoffRetryPolicy) Retry(req *http.Request, err error, attempt int, elapsed time.Duration) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || req.Context().Err() != nil {
+		return 0, false
+	}
+
+	wait := p.Backoff(attempt)
+
+	var rateLimitErr RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		if after := time.Duration(rateLimitErr.RetryAfter) * time.Second; after > wait {
+			wait = after
+		}
+	case graphQLThrottled(err):
+	case retryableStatus(err) || retryableTransportError(err):
+		idempotent := p.Idempotent
+		if idempotent == nil {
+			idempotent = IsIdempotent
+		}
+		if !idempotent(req) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if p.MaxElapsed > 0 && elapsed+wait > p.MaxElapsed {
+		return 0, false
+	}
+	return wait, true
+}
+
+// Backoff returns the wait after attempt, counting from 1, failed.
+func (p *BackoffRetryPolicy) Backoff(attempt int) time.Duration {
+	wait := float64(p.MinBackoff) * math.Pow(2, float64(attempt-1))
+	if p.MaxBackoff > 0 && wait > float64(p.MaxBackoff) {
+		wait = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		wait -= wait * p.Jitter * rand.Float64()
+	}
+	return time.Duration(wait)
+}
+
+// IsIdempotent reports whether sending req several times has the same effect
+// as sending it once: GET, HEAD, OPTIONS, PUT and DELETE requests, and
+// requests carrying an Idempotency-Key header.
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// responseStatus returns the status of the response err was built from, 0
+// if it is not a response error.
+func responseStatus(err error) int {
+	var respErr ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.Status
+	}
+	// gateways answer with html pages Shopify's errors can not be read from
+	var decodingErr ResponseDecodingError
+	if errors.As(err, &decodingErr) {
+		return decodingErr.Status
+	}
+	return 0
+}
+
+// retryableStatus reports whether err is a response error with a status
+// worth retrying.
+func retryableStatus(err error) bool {
+	switch responseStatus(err) {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryableTransportError reports whether err is a network error, such as a
+// timeout or a dropped connection. Errors caused by the request's context are
+// ruled out by checking the context.
+func retryableTransportError(err error) bool {
+	// url.Error implements net.Error itself, look at the error it wraps
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// legacyRetryPolicy is used without WithRetryPolicy. It makes up to attempts
+// attempts, see WithRetry, retrying rate limited requests after their
+// Retry-After delay, throttled GraphQL queries once their cost is restored
+// and 503 responses immediately.
+type legacyRetryPolicy struct {
+	attempts int
+}
+
+func (p legacyRetryPolicy) Retry(req *http.Request, err error, attempt int, elapsed time.Duration) (time.Duration, bool) {
+	if attempt >= p.attempts {
+		return 0, false
+	}
+
+	var rateLimitErr RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return time.Duration(rateLimitErr.RetryAfter) * time.Second, true
+	}
+
+	if graphQLThrottled(err) {
+		return 0, true
+	}
+
+	return 0, responseStatus(err) == http.StatusServiceUnavailable
+}
+
+// graphQLThrottled reports whether err is a throttled GraphQL query.
+func graphQLThrottled(err error) bool {
+	var graphQLErrs GraphQLErrors
+	return errors.As(err, &graphQLErrs) && graphQLErrs.Throttled()
+}
+
+// policy returns the RetryPolicy of the client, see WithRetryPolicy.
+func (c *Client) policy() RetryPolicy {
+	if c.retryPolicy == nil {
+		return legacyRetryPolicy{attempts: c.retries}
+	}
+	return c.retryPolicy
+}
@@ -0,0 +1,146 @@
+package shopify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+// timeoutError is a net.Error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestBackoffRetryPolicyRetry(t *testing.T) {
+	policy := &BackoffRetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Second,
+		MaxBackoff:  10 * time.Second,
+		MaxElapsed:  time.Minute,
+	}
+
+	get, _ := http.NewRequest(http.MethodGet, "https://fooshop.myshopify.com/admin/products.json", nil)
+	post, _ := http.NewRequest(http.MethodPost, "https://fooshop.myshopify.com/admin/products.json", nil)
+	keyed, _ := http.NewRequest(http.MethodPost, "https://fooshop.myshopify.com/admin/products.json", nil)
+	keyed.Header.Set("Idempotency-Key", "abc")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled := get.WithContext(ctx)
+
+	transportErr := &url.Error{Op: "Get", URL: get.URL.String(), Err: timeoutError{}}
+	rateLimited := RateLimitError{ResponseError: ResponseError{Status: 429}, RetryAfter: 5}
+
+	cases := []struct {
+		name    string
+		req     *http.Request
+		err     error
+		attempt int
+		elapsed time.Duration
+		wait    time.Duration
+		retry   bool
+	}{
+		{"502 get", get, ServerError{ResponseError{Status: 502}}, 1, 0, time.Second, true},
+		{"500 get", get, ServerError{ResponseError{Status: 500}}, 2, 0, 2 * time.Second, true},
+		{"504 html get", get, ResponseDecodingError{Status: 504}, 1, 0, time.Second, true},
+		{"501 get", get, ServerError{ResponseError{Status: 501}}, 1, 0, 0, false},
+		{"502 post", post, ServerError{ResponseError{Status: 502}}, 1, 0, 0, false},
+		{"502 post with key", keyed, ServerError{ResponseError{Status: 502}}, 1, 0, time.Second, true},
+		{"429 post", post, rateLimited, 1, 0, 5 * time.Second, true},
+		{"timeout get", get, transportErr, 1, 0, time.Second, true},
+		{"timeout post", post, transportErr, 1, 0, 0, false},
+		{"other transport error", get, &url.Error{Op: "Get", URL: get.URL.String(), Err: errors.New("unsupported protocol scheme")}, 1, 0, 0, false},
+		{"404 get", get, NotFoundError{ResponseError{Status: 404}}, 1, 0, 0, false},
+		{"attempts exhausted", get, ServerError{ResponseError{Status: 502}}, 3, 0, 0, false},
+		{"max elapsed", get, ServerError{ResponseError{Status: 502}}, 1, 59500 * time.Millisecond, 0, false},
+		{"context cancelled", cancelled, transportErr, 1, 0, 0, false},
+	}
+
+	for _, c := range cases {
+		wait, retry := policy.Retry(c.req, c.err, c.attempt, c.elapsed)
+		if retry != c.retry || wait != c.wait {
+			t.Errorf("%s: Retry() = %s, %v, expected %s, %v", c.name, wait, retry, c.wait, c.retry)
+		}
+	}
+}
+
+func TestBackoffRetryPolicyBackoff(t *testing.T) {
+	policy := &BackoffRetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, e := range expected {
+		if wait := policy.Backoff(i + 1); wait != e {
+			t.Errorf("Backoff(%d) = %s, expected %s", i+1, wait, e)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if wait := policy.Backoff(2); wait < time.Second || wait > 2*time.Second {
+			t.Fatalf("Backoff(2) with jitter = %s, expected between 1s and 2s", wait)
+		}
+	}
+}
+
+func TestDoRetryPolicy(t *testing.T) {
+	setup()
+	defer teardown()
+
+	policy := NewBackoffRetryPolicy(3)
+	policy.MinBackoff = time.Millisecond
+	client := NewClient(app, "fooshop", "abcd", WithVersion(testApiVersion), WithRetryPolicy(policy))
+	httpmock.ActivateNonDefault(client.Client)
+
+	url := fmt.Sprintf("https://fooshop.myshopify.com/%s/foo", client.pathPrefix)
+
+	// idempotent requests are retried with their body
+	var bodies []string
+	httpmock.RegisterResponder("PUT", url, func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			return nil, timeoutError{}
+		}
+		if len(bodies) == 2 {
+			return httpmock.NewStringResponse(http.StatusBadGateway, "<html></html>"), nil
+		}
+		return httpmock.NewStringResponse(http.StatusOK, `{"foo": "bar"}`), nil
+	})
+
+	var resp Response
+	err := client.WithResponse(&resp).Put("foo", map[string]string{"foo": "bar"}, nil)
+	if err != nil {
+		t.Fatalf("Put(): unexpected error %v", err)
+	}
+	if resp.Attempts != 3 {
+		t.Errorf("Put(): attempts = %d, expected 3", resp.Attempts)
+	}
+	for _, body := range bodies {
+		if body != `{"foo":"bar"}` {
+			t.Errorf("Put(): body = %q, expected it to be replayed", body)
+		}
+	}
+
+	// creating a resource is not replayed
+	httpmock.RegisterResponder("POST", url, httpmock.NewStringResponder(http.StatusBadGateway, ""))
+
+	err = client.WithResponse(&resp).Post("foo", map[string]string{"foo": "bar"}, nil)
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("Post(): expected ServerError, actual %#v", err)
+	}
+	if resp.Attempts != 1 || resp.Status != http.StatusBadGateway {
+		t.Errorf("Post(): attempts = %d, status = %d, expected 1, 502", resp.Attempts, resp.Status)
+	}
+}
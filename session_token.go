@@ -0,0 +1,178 @@
+package shopify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultSessionTokenLeeway is the clock skew tolerated by
+// SessionTokenMiddleware when checking the expiry of session tokens.
+const DefaultSessionTokenLeeway = 5 * time.Second
+
+// ErrInvalidSessionToken is matched with errors.Is by every error returned
+// by App.VerifySessionToken.
+var ErrInvalidSessionToken = errors.New("shopify: invalid session token")
+
+// SessionTokenClaims are the claims of a session token, the JWT App Bridge
+// sends embedded apps in the Authorization header.
+// See: https://shopify.dev/apps/auth/oauth/session-tokens
+type SessionTokenClaims struct {
+	// Issuer is the admin url of the shop, e.g.
+	// "https://theshop.myshopify.com/admin".
+	Issuer string `json:"iss"`
+	// Dest is the url of the shop, e.g. "https://theshop.myshopify.com".
+	Dest string `json:"dest"`
+	// Audience is the api key of the app.
+	Audience string `json:"aud"`
+	// Subject is the id of the staff member using the app.
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf"`
+	IssuedAt  int64  `json:"iat"`
+	ID        string `json:"jti"`
+	SessionID string `json:"sid"`
+}
+
+// Shop returns the myshopify domain of the shop the token was issued by.
+func (c SessionTokenClaims) Shop() string {
+	u, err := url.Parse(c.Dest)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// VerifySessionToken verifies a session token sent by App Bridge and returns
+// its claims. The token must be signed with the ApiSecret of the app, issued
+// for its ApiKey and issued by a shop. leeway is the clock skew tolerated
+// when checking the expiry of the token.
+func (app App) VerifySessionToken(token string, leeway time.Duration) (*SessionTokenClaims, error) {
+	if app.ApiSecret == "" {
+		return nil, sessionTokenError("ApiSecret is empty")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, sessionTokenError("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSessionTokenPart(parts[0], &header); err != nil {
+		return nil, sessionTokenError("malformed header: %v", err)
+	}
+	// the algorithm is fixed, the header is not trusted to pick one
+	if header.Alg != "HS256" {
+		return nil, sessionTokenError("unexpected algorithm %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, sessionTokenError("malformed signature: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(app.ApiSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, sessionTokenError("invalid signature")
+	}
+
+	claims := new(SessionTokenClaims)
+	if err := decodeSessionTokenPart(parts[1], claims); err != nil {
+		return nil, sessionTokenError("malformed claims: %v", err)
+	}
+
+	if claims.Audience != app.ApiKey {
+		return nil, sessionTokenError("issued for %q", claims.Audience)
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)) {
+		return nil, sessionTokenError("expired")
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, sessionTokenError("not valid yet")
+	}
+
+	dest, err := url.Parse(claims.Dest)
+	if err != nil || !ValidShopDomain(dest.Host) {
+		return nil, sessionTokenError("invalid dest %q", claims.Dest)
+	}
+	iss, err := url.Parse(claims.Issuer)
+	if err != nil || iss.Host != dest.Host {
+		return nil, sessionTokenError("issuer %q does not match dest %q", claims.Issuer, claims.Dest)
+	}
+
+	return claims, nil
+}
+
+// SessionTokenMiddleware returns middleware verifying the session token in
+// the Authorization header of requests, see App.VerifySessionToken. The
+// claims of the token are passed to the wrapped handler in the context of
+// the request, see SessionTokenFromContext. Requests without a valid token
+// are answered with 401 Unauthorized and the header asking App Bridge to
+// retry with a fresh token.
+//
+//	http.Handle("/api/", app.SessionTokenMiddleware(shopify.DefaultSessionTokenLeeway)(apiHandler))
+func (app App) SessionTokenMiddleware(leeway time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			var claims *SessionTokenClaims
+			var err error
+			if ok {
+				claims, err = app.VerifySessionToken(token, leeway)
+			}
+			if !ok || err != nil {
+				w.Header().Set("X-Shopify-Retry-Invalid-Session-Request", "1")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ContextWithSessionToken(r.Context(), claims)))
+		})
+	}
+}
+
+type sessionTokenContextKey struct{}
+
+// ContextWithSessionToken returns a copy of ctx carrying claims.
+func ContextWithSessionToken(ctx context.Context, claims *SessionTokenClaims) context.Context {
+	return context.WithValue(ctx, sessionTokenContextKey{}, claims)
+}
+
+// SessionTokenFromContext returns the claims put into ctx by
+// SessionTokenMiddleware.
+func SessionTokenFromContext(ctx context.Context) (*SessionTokenClaims, bool) {
+	claims, ok := ctx.Value(sessionTokenContextKey{}).(*SessionTokenClaims)
+	return claims, ok
+}
+
+// bearerToken returns the token of the Authorization header of r.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return header[7:], true
+}
+
+func decodeSessionTokenPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func sessionTokenError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSessionToken, fmt.Sprintf(format, args...))
+}
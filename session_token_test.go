@@ -0,0 +1,132 @@
+package shopify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// signSessionToken returns the session token App Bridge would send for
+// claims.
+func signSessionToken(secret string, alg string, claims interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func testSessionTokenClaims() SessionTokenClaims {
+	now := time.Now().Unix()
+	return SessionTokenClaims{
+		Issuer:    "https://fooshop.myshopify.com/admin",
+		Dest:      "https://fooshop.myshopify.com",
+		Audience:  "apikey",
+		Subject:   "42",
+		ExpiresAt: now + 60,
+		NotBefore: now,
+		IssuedAt:  now,
+		ID:        "f8912129-1af6-4cad-9ca3-76b0f7621087",
+		SessionID: "aaea182f2732d44c23057c0fea584021a4485b2bd25d3eb7fd349313ad24c685",
+	}
+}
+
+func TestAppVerifySessionToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	valid := testSessionTokenClaims()
+	claims, err := app.VerifySessionToken(signSessionToken(app.ApiSecret, "HS256", valid), 0)
+	if err != nil {
+		t.Fatalf("App.VerifySessionToken() returned error: %v", err)
+	}
+	if *claims != valid {
+		t.Errorf("App.VerifySessionToken() = %#v, expected %#v", claims, valid)
+	}
+	if shop := claims.Shop(); shop != "fooshop.myshopify.com" {
+		t.Errorf("Shop() = %s, expected fooshop.myshopify.com", shop)
+	}
+
+	modify := func(f func(c *SessionTokenClaims)) SessionTokenClaims {
+		c := testSessionTokenClaims()
+		f(&c)
+		return c
+	}
+	now := time.Now().Unix()
+
+	cases := []struct {
+		name   string
+		token  string
+		leeway time.Duration
+		valid  bool
+	}{
+		{"malformed", "abcd", 0, false},
+		{"wrong secret", signSessionToken("wrong", "HS256", valid), 0, false},
+		{"wrong algorithm", signSessionToken(app.ApiSecret, "none", valid), 0, false},
+		{"wrong audience", signSessionToken(app.ApiSecret, "HS256", modify(func(c *SessionTokenClaims) { c.Audience = "otherapp" })), 0, false},
+		{"expired", signSessionToken(app.ApiSecret, "HS256", modify(func(c *SessionTokenClaims) { c.ExpiresAt = now - 2 })), 0, false},
+		{"expired within leeway", signSessionToken(app.ApiSecret, "HS256", modify(func(c *SessionTokenClaims) { c.ExpiresAt = now - 2 })), 5 * time.Second, true},
+		{"not valid yet", signSessionToken(app.ApiSecret, "HS256", modify(func(c *SessionTokenClaims) { c.NotBefore = now + 30 })), 5 * time.Second, false},
+		{"not valid yet within leeway", signSessionToken(app.ApiSecret, "HS256", modify(func(c *SessionTokenClaims) { c.NotBefore = now + 2 })), 5 * time.Second, true},
+		{"dest not a shop", signSessionToken(app.ApiSecret, "HS256", modify(func(c *SessionTokenClaims) { c.Dest = "https://evil.com" })), 0, false},
+		{"issuer of another shop", signSessionToken(app.ApiSecret, "HS256", modify(func(c *SessionTokenClaims) { c.Issuer = "https://barshop.myshopify.com/admin" })), 0, false},
+	}
+	for _, c := range cases {
+		_, err := app.VerifySessionToken(c.token, c.leeway)
+		if c.valid && err != nil {
+			t.Errorf("%s: App.VerifySessionToken() returned error: %v", c.name, err)
+		}
+		if !c.valid && !errors.Is(err, ErrInvalidSessionToken) {
+			t.Errorf("%s: App.VerifySessionToken() returned %v, expected ErrInvalidSessionToken", c.name, err)
+		}
+	}
+
+	if _, err := (App{ApiKey: "apikey"}).VerifySessionToken(signSessionToken("", "HS256", valid), 0); !errors.Is(err, ErrInvalidSessionToken) {
+		t.Errorf("App.VerifySessionToken() without ApiSecret returned %v, expected ErrInvalidSessionToken", err)
+	}
+}
+
+func TestSessionTokenMiddleware(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got *SessionTokenClaims
+	handler := app.SessionTokenMiddleware(DefaultSessionTokenLeeway)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = SessionTokenFromContext(r.Context())
+	}))
+
+	claims := testSessionTokenClaims()
+	req := httptest.NewRequest("GET", "/api/products", nil)
+	req.Header.Set("Authorization", "Bearer "+signSessionToken(app.ApiSecret, "HS256", claims))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("middleware answered %d, expected 200", w.Code)
+	}
+	if got == nil || *got != claims {
+		t.Errorf("claims in context = %#v, expected %#v", got, claims)
+	}
+
+	got = nil
+	for _, header := range []string{"", "Bearer abcd", signSessionToken(app.ApiSecret, "HS256", claims), "Bearer " + signSessionToken("wrong", "HS256", claims)} {
+		req := httptest.NewRequest("GET", "/api/products", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized || w.Header().Get("X-Shopify-Retry-Invalid-Session-Request") != "1" {
+			t.Errorf("middleware answered %d to %q, expected 401 asking for a retry", w.Code, header)
+		}
+		if got != nil {
+			t.Errorf("handler called for %q", header)
+		}
+	}
+}
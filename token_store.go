@@ -0,0 +1,312 @@
+package shopify
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTokenNotFound is returned by a TokenStore holding no token for a shop.
+var ErrTokenNotFound = errors.New("shopify: no access token stored for shop")
+
+// ErrTokenExpired is returned by clients of an expired online token, see
+// WithTokenExpiry.
+var ErrTokenExpired = errors.New("shopify: access token expired")
+
+// AccessToken is an access token granted to the app by a shop.
+type AccessToken struct {
+	// Shop is the myshopify domain of the shop, e.g. "theshop.myshopify.com".
+	Shop  string `json:"shop"`
+	Token string `json:"access_token"`
+	// Scope is the comma separated list of scopes granted to the token.
+	Scope     string    `json:"scope"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// The fields below are only set for online tokens, see WithPerUserGrant.
+
+	// ExpiresIn is the number of seconds the token was valid for when it was
+	// granted, ExpiresAt when it expires.
+	ExpiresIn int       `json:"expires_in,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// AssociatedUserScope is the comma separated list of scopes available to
+	// the staff member, a subset of Scope.
+	AssociatedUserScope string          `json:"associated_user_scope,omitempty"`
+	AssociatedUser      *AssociatedUser `json:"associated_user,omitempty"`
+}
+
+// AssociatedUser is the staff member an online token was granted to.
+type AssociatedUser struct {
+	ID            int64  `json:"id"`
+	FirstName     string `json:"first_name"`
+	LastName      string `json:"last_name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	AccountOwner  bool   `json:"account_owner"`
+	Locale        string `json:"locale"`
+	Collaborator  bool   `json:"collaborator"`
+}
+
+// Online reports whether the token is an online token, granted to a staff
+// member for the duration of their session.
+func (t AccessToken) Online() bool {
+	return t.AssociatedUser != nil || t.ExpiresIn > 0
+}
+
+// Expired reports whether the token expired, permanent tokens never do.
+func (t AccessToken) Expired() bool {
+	return !t.ExpiresAt.IsZero() && !time.Now().Before(t.ExpiresAt)
+}
+
+// Scopes returns the scopes granted to the token.
+func (t AccessToken) Scopes() []string {
+	if t.Scope == "" {
+		return nil
+	}
+	scopes := strings.Split(t.Scope, ",")
+	for i := range scopes {
+		scopes[i] = strings.TrimSpace(scopes[i])
+	}
+	return scopes
+}
+
+// TokenStore persists the access tokens of the shops the app is installed
+// on. App.RequestAccessToken saves the tokens it obtains into the store of
+// the app, NewClientForShop looks them up and tokens are deleted when the
+// app is uninstalled. Implementations must be safe for concurrent use.
+type TokenStore interface {
+	// Save stores token, replacing the token stored for its shop.
+	Save(token AccessToken) error
+	// Load returns the token of shop, ErrTokenNotFound if there is none.
+	Load(shop string) (*AccessToken, error)
+	// Delete removes the token of shop, it is not an error if there is none.
+	Delete(shop string) error
+	// Shops returns the shops a token is stored for.
+	Shops() ([]string, error)
+}
+
+// StoreTokenLookup returns a TokenLookup reading tokens from store, e.g. for
+// a ClientPool.
+func StoreTokenLookup(store TokenStore) TokenLookup {
+	return TokenLookupFunc(func(shop string) (string, error) {
+		token, err := store.Load(shop)
+		if err != nil {
+			return "", err
+		}
+		return token.Token, nil
+	})
+}
+
+// NewClientForShop returns a client for shop using the token stored in the
+// TokenStore of app. It returns ErrTokenNotFound when the store holds no
+// token for shop.
+func NewClientForShop(app App, shop string, opts ...Option) (*Client, error) {
+	if app.TokenStore == nil {
+		return nil, errors.New("shopify: app has no TokenStore")
+	}
+
+	token, err := app.TokenStore.Load(shop)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClientWithToken(app, *token, opts...), nil
+}
+
+// NewClientWithToken returns a client for the shop of token. Clients of
+// online tokens refuse to send requests once the token expired, see
+// WithTokenExpiry.
+func NewClientWithToken(app App, token AccessToken, opts ...Option) *Client {
+	if !token.ExpiresAt.IsZero() {
+		opts = append([]Option{WithTokenExpiry(token.ExpiresAt)}, opts...)
+	}
+	return NewClient(app, token.Shop, token.Token, opts...)
+}
+
+// shopKey returns the key of shop in stores and pools, its lower case
+// myshopify domain.
+func shopKey(shop string) string {
+	return strings.ToLower(ShopFullName(shop))
+}
+
+// MemoryTokenStore is a TokenStore keeping tokens in memory, for tests and
+// single process apps that do not mind reinstalling.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]AccessToken
+}
+
+// NewMemoryTokenStore returns an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: map[string]AccessToken{}}
+}
+
+// Save stores token.
+func (s *MemoryTokenStore) Save(token AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token.Shop = shopKey(token.Shop)
+	s.tokens[token.Shop] = token
+	return nil
+}
+
+// Load returns the token of shop.
+func (s *MemoryTokenStore) Load(shop string) (*AccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	token, ok := s.tokens[shopKey(shop)]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &token, nil
+}
+
+// Delete removes the token of shop.
+func (s *MemoryTokenStore) Delete(shop string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, shopKey(shop))
+	return nil
+}
+
+// Shops returns the shops a token is stored for, sorted.
+func (s *MemoryTokenStore) Shops() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedShops(s.tokens), nil
+}
+
+// FileTokenStore is a TokenStore keeping tokens in a JSON file. The file is
+// read on every call and replaced atomically on every change, so it suits
+// apps installed on a moderate number of shops. The file holds secrets and is
+// created readable by its owner only.
+type FileTokenStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileTokenStore returns a FileTokenStore keeping tokens in the file at
+// path, which is created on the first Save.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Save stores token.
+func (s *FileTokenStore) Save(token AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	token.Shop = shopKey(token.Shop)
+	tokens[token.Shop] = token
+	return s.write(tokens)
+}
+
+// Load returns the token of shop.
+func (s *FileTokenStore) Load(shop string) (*AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	token, ok := tokens[shopKey(shop)]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	return &token, nil
+}
+
+// Delete removes the token of shop.
+func (s *FileTokenStore) Delete(shop string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[shopKey(shop)]; !ok {
+		return nil
+	}
+	delete(tokens, shopKey(shop))
+	return s.write(tokens)
+}
+
+// Shops returns the shops a token is stored for, sorted.
+func (s *FileTokenStore) Shops() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	return sortedShops(tokens), nil
+}
+
+// read returns the tokens in the file, none if it does not exist yet.
+func (s *FileTokenStore) read() (map[string]AccessToken, error) {
+	tokens := map[string]AccessToken{}
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &tokens); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+// write replaces the file with tokens, writing a temporary file first so a
+// crash does not leave a truncated file behind.
+func (s *FileTokenStore) write(tokens map[string]AccessToken) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func sortedShops(tokens map[string]AccessToken) []string {
+	shops := make([]string, 0, len(tokens))
+	for shop := range tokens {
+		shops = append(shops, shop)
+	}
+	sort.Strings(shops)
+	return shops
+}
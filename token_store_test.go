@@ -0,0 +1,175 @@
+package shopify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func testTokenStore(t *testing.T, store TokenStore) {
+	t.Helper()
+
+	if _, err := store.Load("fooshop"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Load() of an unknown shop returned %v, expected ErrTokenNotFound", err)
+	}
+
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tokens := []AccessToken{
+		{Shop: "FooShop", Token: "footoken", Scope: "read_products,write_orders", CreatedAt: createdAt},
+		{Shop: "barshop.myshopify.com", Token: "bartoken", Scope: "read_orders", CreatedAt: createdAt},
+	}
+	for _, token := range tokens {
+		if err := store.Save(token); err != nil {
+			t.Fatalf("Save() returned error: %v", err)
+		}
+	}
+
+	token, err := store.Load("fooshop.myshopify.com")
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	expected := &AccessToken{Shop: "fooshop.myshopify.com", Token: "footoken", Scope: "read_products,write_orders", CreatedAt: createdAt}
+	if !reflect.DeepEqual(token, expected) {
+		t.Errorf("Load() = %#v, expected %#v", token, expected)
+	}
+
+	// saving again replaces the token
+	if err := store.Save(AccessToken{Shop: "fooshop", Token: "newtoken"}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if token, _ := store.Load("fooshop"); token == nil || token.Token != "newtoken" {
+		t.Errorf("Load() after a second Save() = %#v", token)
+	}
+
+	shops, err := store.Shops()
+	if err != nil {
+		t.Fatalf("Shops() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(shops, []string{"barshop.myshopify.com", "fooshop.myshopify.com"}) {
+		t.Errorf("Shops() = %v", shops)
+	}
+
+	if err := store.Delete("fooshop"); err != nil {
+		t.Fatalf("Delete() returned error: %v", err)
+	}
+	if err := store.Delete("fooshop"); err != nil {
+		t.Errorf("Delete() of a deleted shop returned error: %v", err)
+	}
+	if _, err := store.Load("fooshop"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("Load() of a deleted shop returned %v, expected ErrTokenNotFound", err)
+	}
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	testTokenStore(t, NewMemoryTokenStore())
+}
+
+func TestFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	testTokenStore(t, NewFileTokenStore(path))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("token file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("token file mode = %v, expected 0600", info.Mode().Perm())
+	}
+
+	// a new store reads the tokens written by another
+	token, err := NewFileTokenStore(path).Load("barshop")
+	if err != nil || token.Token != "bartoken" {
+		t.Errorf("Load() from a new store = %#v, %v", token, err)
+	}
+
+	os.WriteFile(path, []byte("{"), 0600)
+	if _, err := NewFileTokenStore(path).Load("barshop"); err == nil {
+		t.Error("Load() from a corrupt file: expected an error")
+	}
+}
+
+func TestAccessTokenScopes(t *testing.T) {
+	token := AccessToken{Scope: "read_products, write_orders"}
+	if scopes := token.Scopes(); !reflect.DeepEqual(scopes, []string{"read_products", "write_orders"}) {
+		t.Errorf("Scopes() = %v", scopes)
+	}
+	if scopes := (AccessToken{}).Scopes(); scopes != nil {
+		t.Errorf("Scopes() of no scope = %v", scopes)
+	}
+}
+
+func TestAccessTokenExpired(t *testing.T) {
+	cases := []struct {
+		token   AccessToken
+		online  bool
+		expired bool
+	}{
+		{AccessToken{Token: "offline"}, false, false},
+		{AccessToken{ExpiresIn: 60, ExpiresAt: time.Now().Add(time.Minute)}, true, false},
+		{AccessToken{ExpiresIn: 60, ExpiresAt: time.Now().Add(-time.Second)}, true, true},
+		{AccessToken{AssociatedUser: &AssociatedUser{ID: 1}}, true, false},
+	}
+	for _, c := range cases {
+		if online := c.token.Online(); online != c.online {
+			t.Errorf("%#v Online() = %v, expected %v", c.token, online, c.online)
+		}
+		if expired := c.token.Expired(); expired != c.expired {
+			t.Errorf("%#v Expired() = %v, expected %v", c.token, expired, c.expired)
+		}
+	}
+}
+
+func TestNewClientWithToken(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/shop.json", client.pathPrefix),
+		httpmock.NewStringResponder(200, `{"shop":{"id":1}}`))
+
+	token := AccessToken{Shop: "fooshop", Token: "onlinetoken", ExpiresIn: 60, ExpiresAt: time.Now().Add(time.Minute)}
+	c := NewClientWithToken(app, token, WithVersion(testApiVersion), WithHTTPClient(client.Client))
+	if _, err := c.Shop.Get(nil); err != nil {
+		t.Errorf("Shop.Get() with a valid token returned %v", err)
+	}
+
+	token.ExpiresAt = time.Now().Add(-time.Second)
+	c = NewClientWithToken(app, token, WithVersion(testApiVersion), WithHTTPClient(client.Client))
+	if _, err := c.Shop.Get(nil); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Shop.Get() with an expired token returned %v, expected ErrTokenExpired", err)
+	}
+	if calls := httpmock.GetTotalCallCount(); calls != 1 {
+		t.Errorf("%d requests were sent, expected 1", calls)
+	}
+}
+
+func TestNewClientForShop(t *testing.T) {
+	store := NewMemoryTokenStore()
+	store.Save(AccessToken{Shop: "fooshop", Token: "footoken"})
+
+	a := App{ApiKey: "apikey", TokenStore: store}
+	c, err := NewClientForShop(a, "FooShop.myshopify.com", WithVersion(testApiVersion))
+	if err != nil {
+		t.Fatalf("NewClientForShop() returned error: %v", err)
+	}
+	if c.token != "footoken" || c.baseURL.String() != "https://fooshop.myshopify.com" || c.pathPrefix != "admin/api/"+testApiVersion {
+		t.Errorf("NewClientForShop() = token %s, url %s, prefix %s", c.token, c.baseURL, c.pathPrefix)
+	}
+
+	if _, err := NewClientForShop(a, "barshop"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("NewClientForShop() of an unknown shop returned %v", err)
+	}
+	if _, err := NewClientForShop(App{}, "fooshop"); err == nil {
+		t.Error("NewClientForShop() without store: expected an error")
+	}
+
+	token, err := StoreTokenLookup(store).Token("fooshop")
+	if err != nil || token != "footoken" {
+		t.Errorf("StoreTokenLookup().Token() = %s, %v", token, err)
+	}
+}
@@ -0,0 +1,48 @@
+package shopify
+
+// UninstallHook is called with the myshopify domain of a shop that
+// uninstalled the app, to purge its tokens and stop the jobs working for it.
+// It may be called more than once for the same shop, e.g. by the client of
+// each process and by the app/uninstalled webhook, and must be idempotent.
+type UninstallHook func(shop string) error
+
+// PurgeOnUninstall returns an UninstallHook deleting the token of the shop
+// from store and evicting its client from pool. Either may be nil.
+func PurgeOnUninstall(store TokenStore, pool *ClientPool) UninstallHook {
+	return func(shop string) error {
+		if pool != nil {
+			pool.Evict(shop)
+		}
+		if store != nil {
+			return store.Delete(shop)
+		}
+		return nil
+	}
+}
+
+// OnUninstall registers hook for the app/uninstalled topic, replacing any
+// callback registered with OnAppUninstalled.
+func (h *WebhookHandler) OnUninstall(hook UninstallHook) {
+	h.Handle(TopicAppUninstalled, func(d WebhookDelivery) error {
+		return hook(shopKey(d.ShopDomain))
+	})
+}
+
+// reportUninstall calls the uninstall hook of the client the first time its
+// token is found revoked.
+func (c *Client) reportUninstall() {
+	c.state.mu.Lock()
+	called := c.state.uninstalled
+	c.state.uninstalled = true
+	c.state.mu.Unlock()
+
+	if called {
+		return
+	}
+
+	shop := shopKey(c.baseURL.Host)
+	c.log.Warnf("access token of %s was revoked, the app was uninstalled", shop)
+	if err := c.uninstallHook(shop); err != nil {
+		c.log.Errorf("uninstall hook of %s failed: %v", shop, err)
+	}
+}
@@ -0,0 +1,80 @@
+package shopify
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+)
+
+func TestWithUninstallHook(t *testing.T) {
+	setup()
+	defer teardown()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/shop.json", client.pathPrefix),
+		httpmock.NewStringResponder(401, `{"errors":"[API] Invalid API key or access token (unrecognized login or wrong password)"}`))
+	httpmock.RegisterResponder("GET", fmt.Sprintf("https://fooshop.myshopify.com/%s/products/1.json", client.pathPrefix),
+		httpmock.NewStringResponder(404, `{"errors":"Not Found"}`))
+
+	var uninstalled []string
+	c := NewClient(app, "fooshop", "abcd",
+		WithVersion(testApiVersion),
+		WithHTTPClient(client.Client),
+		WithUninstallHook(func(shop string) error {
+			uninstalled = append(uninstalled, shop)
+			return errors.New("store unavailable")
+		}))
+
+	if _, err := c.Product.Get(1, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Product.Get() returned %v, expected ErrNotFound", err)
+	}
+	if len(uninstalled) != 0 {
+		t.Errorf("uninstall hook called for a 404: %v", uninstalled)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Shop.Get(nil); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("Shop.Get() returned %v, expected ErrTokenRevoked", err)
+		}
+	}
+	if !reflect.DeepEqual(uninstalled, []string{"fooshop.myshopify.com"}) {
+		t.Errorf("uninstall hook called with %v, expected once with fooshop.myshopify.com", uninstalled)
+	}
+}
+
+func TestWebhookHandlerOnUninstall(t *testing.T) {
+	setup()
+	defer teardown()
+
+	store := NewMemoryTokenStore()
+	store.Save(AccessToken{Shop: "fooshop", Token: "footoken"})
+	store.Save(AccessToken{Shop: "barshop", Token: "bartoken"})
+
+	pool := NewClientPool(app, StoreTokenLookup(store))
+	if _, err := pool.Get("fooshop"); err != nil {
+		t.Fatalf("ClientPool.Get() returned error: %v", err)
+	}
+
+	handler := NewWebhookHandler(app)
+	handler.OnUninstall(PurgeOnUninstall(store, pool))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newWebhookRequest(TopicAppUninstalled, `{"id":1,"domain":"fooshop.myshopify.com"}`))
+	if w.Code != http.StatusOK {
+		t.Errorf("app/uninstalled answered %d, expected 200", w.Code)
+	}
+
+	if _, err := store.Load("fooshop"); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("token of the uninstalled shop was not deleted: %v", err)
+	}
+	if _, err := store.Load("barshop"); err != nil {
+		t.Errorf("token of another shop was deleted: %v", err)
+	}
+	if pool.Len() != 0 {
+		t.Errorf("client of the uninstalled shop was not evicted, pool holds %d", pool.Len())
+	}
+}
@@ -15,6 +15,8 @@ const variantsResourceName = "variants"
 // See https://help.shopify.com/api/reference/product_variant
 type VariantService interface {
 	List(int64, interface{}) ([]Variant, error)
+	ListWithPagination(int64, interface{}) ([]Variant, *Pagination, error)
+	ListAll(int64, interface{}, func([]Variant) error) error
 	Count(int64, interface{}) (int, error)
 	Get(int64, interface{}) (*Variant, error)
 	Create(int64, Variant) (*Variant, error)
@@ -81,6 +83,30 @@ func (s *VariantServiceOp) List(productID int64, options interface{}) ([]Variant
 	return resource.Variants, err
 }
 
+// ListWithPagination lists variants and return pagination to retrieve next/previous results.
+func (s *VariantServiceOp) ListWithPagination(productID int64, options interface{}) ([]Variant, *Pagination, error) {
+	path := fmt.Sprintf("%s/%d/variants.json", productsBasePath, productID)
+	resource := new(VariantsResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.Variants, pagination, nil
+}
+
+// ListAll walks every page of variants, calling fn with each page. See Client.ListAll.
+func (s *VariantServiceOp) ListAll(productID int64, options interface{}, fn func([]Variant) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		variants, pagination, err := s.ListWithPagination(productID, options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(variants)
+	})
+}
+
 // Count variants
 func (s *VariantServiceOp) Count(productID int64, options interface{}) (int, error) {
 	path := fmt.Sprintf("%s/%d/variants/count.json", productsBasePath, productID)
@@ -2,6 +2,7 @@ package shopify
 
 import (
 	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -455,3 +456,36 @@ func variantTestsWithTaxCode(t *testing.T, variant Variant) {
 	}
 
 }
+
+func TestVariantListAll(t *testing.T) {
+	setup()
+	defer teardown()
+
+	basePath := fmt.Sprintf("https://fooshop.myshopify.com/%s/products/1/variants.json", client.pathPrefix)
+	httpmock.RegisterResponderWithQuery("GET", basePath, map[string]string{"limit": "1"},
+		httpmock.ResponderFromResponse(&http.Response{
+			StatusCode: 200,
+			Body:       httpmock.NewRespBodyFromString(`{"variants":[{"id":1}]}`),
+			Header: http.Header{
+				"Link": {fmt.Sprintf(`<%s?limit=1&page_info=next>; rel="next"`, basePath)},
+			},
+		}))
+	httpmock.RegisterResponderWithQuery("GET", basePath, map[string]string{"limit": "1", "page_info": "next"},
+		httpmock.NewStringResponder(200, `{"variants":[{"id":2}]}`))
+
+	var ids []int64
+	err := client.Variant.ListAll(1, ListOptions{Limit: 1}, func(variants []Variant) error {
+		for _, variant := range variants {
+			ids = append(ids, variant.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Variant.ListAll returned error: %v", err)
+	}
+
+	expected := []int64{1, 2}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Variant.ListAll returned ids %v, expected %v", ids, expected)
+	}
+}
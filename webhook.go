@@ -2,6 +2,7 @@ package shopify
 
 import (
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -12,11 +13,14 @@ const webhooksBasePath = "webhooks"
 // See: https://help.shopify.com/api/reference/webhook
 type WebhookService interface {
 	List(interface{}) ([]Webhook, error)
+	ListWithPagination(interface{}) ([]Webhook, *Pagination, error)
+	ListAll(interface{}, func([]Webhook) error) error
 	Count(interface{}) (int, error)
 	Get(int64, interface{}) (*Webhook, error)
 	Create(Webhook) (*Webhook, error)
 	Update(Webhook) (*Webhook, error)
 	Delete(int64) error
+	Sync([]Webhook, bool) (*WebhookSyncReport, error)
 }
 
 // WebhookServiceOp handles communication with the webhook-related methods of
@@ -61,6 +65,30 @@ func (s *WebhookServiceOp) List(options interface{}) ([]Webhook, error) {
 	return resource.Webhooks, err
 }
 
+// ListWithPagination lists webhooks and return pagination to retrieve next/previous results.
+func (s *WebhookServiceOp) ListWithPagination(options interface{}) ([]Webhook, *Pagination, error) {
+	path := fmt.Sprintf("%s.json", webhooksBasePath)
+	resource := new(WebhooksResource)
+
+	pagination, err := s.client.ListWithPagination(path, resource, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resource.Webhooks, pagination, nil
+}
+
+// ListAll walks every page of webhooks, calling fn with each page. See Client.ListAll.
+func (s *WebhookServiceOp) ListAll(options interface{}, fn func([]Webhook) error) error {
+	return s.client.paginate(options, func(options interface{}) (*Pagination, error) {
+		webhooks, pagination, err := s.ListWithPagination(options)
+		if err != nil {
+			return nil, err
+		}
+		return pagination, fn(webhooks)
+	})
+}
+
 // Count webhooks
 func (s *WebhookServiceOp) Count(options interface{}) (int, error) {
 	path := fmt.Sprintf("%s/count.json", webhooksBasePath)
@@ -97,3 +125,124 @@ func (s *WebhookServiceOp) Update(webhook Webhook) (*Webhook, error) {
 func (s *WebhookServiceOp) Delete(ID int64) error {
 	return s.client.Delete(fmt.Sprintf("%s/%d.json", webhooksBasePath, ID))
 }
+
+// WebhookSyncReport lists the changes made, or in a dry run the changes that
+// would be made, by WebhookService.Sync.
+type WebhookSyncReport struct {
+	DryRun    bool
+	Created   []Webhook
+	Updated   []Webhook
+	Deleted   []Webhook
+	Unchanged []Webhook
+}
+
+// Sync reconciles the webhook subscriptions of the shop with desired.
+// Subscriptions are matched on topic and address first, then on topic alone.
+// Matched subscriptions whose address, format, fields or metafield namespaces
+// differ are updated, desired ones without a match are created and all other
+// existing subscriptions are deleted. With dryRun nothing is changed and the
+// report lists what would be done.
+func (s *WebhookServiceOp) Sync(desired []Webhook, dryRun bool) (*WebhookSyncReport, error) {
+	var existing []Webhook
+	err := s.ListAll(ListOptions{Limit: 250}, func(webhooks []Webhook) error {
+		existing = append(existing, webhooks...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &WebhookSyncReport{DryRun: dryRun}
+	matched := make([]bool, len(existing))
+	pending := []Webhook{}
+
+	match := func(want Webhook, sameAddress bool) bool {
+		for i, have := range existing {
+			if matched[i] || have.Topic != want.Topic || (sameAddress && have.Address != want.Address) {
+				continue
+			}
+			matched[i] = true
+			want.ID = have.ID
+			if webhookEqual(have, want) {
+				report.Unchanged = append(report.Unchanged, have)
+			} else {
+				report.Updated = append(report.Updated, want)
+			}
+			return true
+		}
+		return false
+	}
+
+	for _, want := range desired {
+		// Shopify defaults the format to json, send it explicitly so the
+		// report and the requests agree with webhookEqual
+		if want.Format == "" {
+			want.Format = "json"
+		}
+		if !match(want, true) {
+			pending = append(pending, want)
+		}
+	}
+	for _, want := range pending {
+		if !match(want, false) {
+			report.Created = append(report.Created, want)
+		}
+	}
+	for i, have := range existing {
+		if !matched[i] {
+			report.Deleted = append(report.Deleted, have)
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for i, webhook := range report.Updated {
+		updated, err := s.Update(webhook)
+		if err != nil {
+			return report, err
+		}
+		report.Updated[i] = *updated
+	}
+	for i, webhook := range report.Created {
+		created, err := s.Create(webhook)
+		if err != nil {
+			return report, err
+		}
+		report.Created[i] = *created
+	}
+	for _, webhook := range report.Deleted {
+		if err := s.Delete(webhook.ID); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// webhookEqual reports whether the subscription have already matches want.
+func webhookEqual(have, want Webhook) bool {
+	return have.Address == want.Address &&
+		have.Format == want.Format &&
+		stringSetEqual(have.Fields, want.Fields) &&
+		stringSetEqual(have.MetafieldNamespaces, want.MetafieldNamespaces)
+}
+
+// stringSetEqual reports whether a and b hold the same strings, regardless
+// of their order.
+func stringSetEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,330 @@
+package shopify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Webhook topics with typed callbacks on WebhookHandler.
+// See: https://shopify.dev/api/admin-rest/latest/resources/webhook#event-topics
+const (
+	TopicAppUninstalled    = "app/uninstalled"
+	TopicCustomersCreate   = "customers/create"
+	TopicCustomersUpdate   = "customers/update"
+	TopicCustomersDelete   = "customers/delete"
+	TopicFulfillmentCreate = "fulfillments/create"
+	TopicFulfillmentUpdate = "fulfillments/update"
+	TopicOrdersCreate      = "orders/create"
+	TopicOrdersUpdated     = "orders/updated"
+	TopicOrdersPaid        = "orders/paid"
+	TopicOrdersCancelled   = "orders/cancelled"
+	TopicOrdersFulfilled   = "orders/fulfilled"
+	TopicOrdersDelete      = "orders/delete"
+	TopicProductsCreate    = "products/create"
+	TopicProductsUpdate    = "products/update"
+	TopicProductsDelete    = "products/delete"
+	TopicShopUpdate        = "shop/update"
+)
+
+// Headers Shopify sends along with every webhook.
+const (
+	webhookTopicHeader      = "X-Shopify-Topic"
+	webhookShopDomainHeader = "X-Shopify-Shop-Domain"
+	webhookIDHeader         = "X-Shopify-Webhook-Id"
+	webhookAPIVersionHeader = "X-Shopify-API-Version"
+)
+
+// WebhookDelivery is a single verified webhook request sent by Shopify.
+type WebhookDelivery struct {
+	Topic      string
+	ShopDomain string
+	WebhookID  string
+	APIVersion string
+	Body       []byte
+}
+
+// Decode decodes the payload of the delivery into v.
+func (d WebhookDelivery) Decode(v interface{}) error {
+	if err := json.Unmarshal(d.Body, v); err != nil {
+		return webhookDecodingError{err}
+	}
+	return nil
+}
+
+// webhookDecodingError is answered with 400 Bad Request instead of 500.
+type webhookDecodingError struct {
+	error
+}
+
+// WebhookHandlerFunc handles a webhook delivery. Returning an error answers
+// the request with 500 Internal Server Error, which makes Shopify retry the
+// delivery.
+type WebhookHandlerFunc func(d WebhookDelivery) error
+
+// WebhookHandler is an http.Handler receiving Shopify webhooks. It verifies
+// the HMAC of every request and dispatches the delivery to the callback
+// registered for its topic. Deliveries of topics without a callback are
+// acknowledged and dropped.
+//
+// Shopify may deliver a webhook more than once. By default the handler
+// remembers the X-Shopify-Webhook-Id of recent deliveries in memory and
+// invokes the callback at most once per delivery, see SetIdempotencyStore.
+//
+//	handler := shopify.NewWebhookHandler(app)
+//	handler.OnOrderCreate(func(shop string, order shopify.Order) error {
+//		...
+//	})
+//	http.Handle("/webhooks", handler)
+type WebhookHandler struct {
+	app App
+
+	mu       sync.RWMutex
+	handlers map[string]WebhookHandlerFunc
+
+	idempotency       IdempotencyStore
+	idempotencyWindow time.Duration
+}
+
+// NewWebhookHandler returns a WebhookHandler verifying requests with the
+// ApiSecret of app.
+func NewWebhookHandler(app App) *WebhookHandler {
+	return &WebhookHandler{
+		app:               app,
+		handlers:          map[string]WebhookHandlerFunc{},
+		idempotency:       NewMemoryIdempotencyStore(DefaultIdempotencyCapacity),
+		idempotencyWindow: DefaultIdempotencyWindow,
+	}
+}
+
+// SetIdempotencyStore replaces the store used to skip redeliveries and how
+// long deliveries are remembered. A nil store invokes callbacks for every
+// delivery.
+func (h *WebhookHandler) SetIdempotencyStore(store IdempotencyStore, window time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.idempotency = store
+	h.idempotencyWindow = window
+}
+
+// Handle registers fn for topic, replacing any callback registered before.
+func (h *WebhookHandler) Handle(topic string, fn WebhookHandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[topic] = fn
+}
+
+// ServeHTTP verifies and dispatches a webhook request.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	delivery, status := readWebhookDelivery(h.app, r)
+	if status != http.StatusOK {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	h.mu.RLock()
+	fn := h.handlers[delivery.Topic]
+	store, window := h.idempotency, h.idempotencyWindow
+	h.mu.RUnlock()
+
+	if fn == nil {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	key := delivery.WebhookID
+	if store != nil && key != "" {
+		claimed, err := store.Claim(key, window)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if !claimed {
+			// already processed, acknowledge the redelivery
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
+
+	if err := fn(*delivery); err != nil {
+		if store != nil && key != "" {
+			store.Release(key)
+		}
+
+		status = http.StatusInternalServerError
+		if _, ok := err.(webhookDecodingError); ok {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// readWebhookDelivery verifies a webhook request and reads it, returning the
+// status to answer with when it is not acceptable.
+func readWebhookDelivery(app App, r *http.Request) (*WebhookDelivery, int) {
+	if r.Method != http.MethodPost {
+		return nil, http.StatusMethodNotAllowed
+	}
+
+	if ok, _ := app.VerifyWebhookRequestVerbose(r); !ok {
+		return nil, http.StatusUnauthorized
+	}
+
+	delivery := &WebhookDelivery{
+		Topic:      r.Header.Get(webhookTopicHeader),
+		ShopDomain: r.Header.Get(webhookShopDomainHeader),
+		WebhookID:  r.Header.Get(webhookIDHeader),
+		APIVersion: r.Header.Get(webhookAPIVersionHeader),
+	}
+	if delivery.Topic == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	// VerifyWebhookRequestVerbose leaves the body readable
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, http.StatusBadRequest
+	}
+	delivery.Body = body
+
+	return delivery, http.StatusOK
+}
+
+func (h *WebhookHandler) onOrder(topic string, fn func(shop string, o Order) error) {
+	h.Handle(topic, func(d WebhookDelivery) error {
+		order := Order{}
+		if err := d.Decode(&order); err != nil {
+			return err
+		}
+		return fn(d.ShopDomain, order)
+	})
+}
+
+func (h *WebhookHandler) onProduct(topic string, fn func(shop string, p Product) error) {
+	h.Handle(topic, func(d WebhookDelivery) error {
+		product := Product{}
+		if err := d.Decode(&product); err != nil {
+			return err
+		}
+		return fn(d.ShopDomain, product)
+	})
+}
+
+func (h *WebhookHandler) onCustomer(topic string, fn func(shop string, c Customer) error) {
+	h.Handle(topic, func(d WebhookDelivery) error {
+		customer := Customer{}
+		if err := d.Decode(&customer); err != nil {
+			return err
+		}
+		return fn(d.ShopDomain, customer)
+	})
+}
+
+func (h *WebhookHandler) onFulfillment(topic string, fn func(shop string, f Fulfillment) error) {
+	h.Handle(topic, func(d WebhookDelivery) error {
+		fulfillment := Fulfillment{}
+		if err := d.Decode(&fulfillment); err != nil {
+			return err
+		}
+		return fn(d.ShopDomain, fulfillment)
+	})
+}
+
+func (h *WebhookHandler) onShop(topic string, fn func(shop string, s Shop) error) {
+	h.Handle(topic, func(d WebhookDelivery) error {
+		shop := Shop{}
+		if err := d.Decode(&shop); err != nil {
+			return err
+		}
+		return fn(d.ShopDomain, shop)
+	})
+}
+
+// OnOrderCreate registers fn for the orders/create topic.
+func (h *WebhookHandler) OnOrderCreate(fn func(shop string, o Order) error) {
+	h.onOrder(TopicOrdersCreate, fn)
+}
+
+// OnOrderUpdated registers fn for the orders/updated topic.
+func (h *WebhookHandler) OnOrderUpdated(fn func(shop string, o Order) error) {
+	h.onOrder(TopicOrdersUpdated, fn)
+}
+
+// OnOrderPaid registers fn for the orders/paid topic.
+func (h *WebhookHandler) OnOrderPaid(fn func(shop string, o Order) error) {
+	h.onOrder(TopicOrdersPaid, fn)
+}
+
+// OnOrderCancelled registers fn for the orders/cancelled topic.
+func (h *WebhookHandler) OnOrderCancelled(fn func(shop string, o Order) error) {
+	h.onOrder(TopicOrdersCancelled, fn)
+}
+
+// OnOrderFulfilled registers fn for the orders/fulfilled topic.
+func (h *WebhookHandler) OnOrderFulfilled(fn func(shop string, o Order) error) {
+	h.onOrder(TopicOrdersFulfilled, fn)
+}
+
+// OnOrderDelete registers fn for the orders/delete topic. Only the ID of
+// the order is set.
+func (h *WebhookHandler) OnOrderDelete(fn func(shop string, o Order) error) {
+	h.onOrder(TopicOrdersDelete, fn)
+}
+
+// OnProductCreate registers fn for the products/create topic.
+func (h *WebhookHandler) OnProductCreate(fn func(shop string, p Product) error) {
+	h.onProduct(TopicProductsCreate, fn)
+}
+
+// OnProductUpdate registers fn for the products/update topic.
+func (h *WebhookHandler) OnProductUpdate(fn func(shop string, p Product) error) {
+	h.onProduct(TopicProductsUpdate, fn)
+}
+
+// OnProductDelete registers fn for the products/delete topic. Only the ID of
+// the product is set.
+func (h *WebhookHandler) OnProductDelete(fn func(shop string, p Product) error) {
+	h.onProduct(TopicProductsDelete, fn)
+}
+
+// OnCustomerCreate registers fn for the customers/create topic.
+func (h *WebhookHandler) OnCustomerCreate(fn func(shop string, c Customer) error) {
+	h.onCustomer(TopicCustomersCreate, fn)
+}
+
+// OnCustomerUpdate registers fn for the customers/update topic.
+func (h *WebhookHandler) OnCustomerUpdate(fn func(shop string, c Customer) error) {
+	h.onCustomer(TopicCustomersUpdate, fn)
+}
+
+// OnCustomerDelete registers fn for the customers/delete topic. Only the ID
+// of the customer is set.
+func (h *WebhookHandler) OnCustomerDelete(fn func(shop string, c Customer) error) {
+	h.onCustomer(TopicCustomersDelete, fn)
+}
+
+// OnFulfillmentCreate registers fn for the fulfillments/create topic.
+func (h *WebhookHandler) OnFulfillmentCreate(fn func(shop string, f Fulfillment) error) {
+	h.onFulfillment(TopicFulfillmentCreate, fn)
+}
+
+// OnFulfillmentUpdate registers fn for the fulfillments/update topic.
+func (h *WebhookHandler) OnFulfillmentUpdate(fn func(shop string, f Fulfillment) error) {
+	h.onFulfillment(TopicFulfillmentUpdate, fn)
+}
+
+// OnShopUpdate registers fn for the shop/update topic.
+func (h *WebhookHandler) OnShopUpdate(fn func(shop string, s Shop) error) {
+	h.onShop(TopicShopUpdate, fn)
+}
+
+// OnAppUninstalled registers fn for the app/uninstalled topic. The payload
+// is the shop the app was removed from.
+func (h *WebhookHandler) OnAppUninstalled(fn func(shop string, s Shop) error) {
+	h.onShop(TopicAppUninstalled, fn)
+}
@@ -0,0 +1,182 @@
+package shopify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// webhookCount makes the webhook id of every request unique.
+var webhookCount int
+
+// newWebhookRequest returns a webhook request for topic signed with the
+// ApiSecret of app.
+func newWebhookRequest(topic string, body string) *http.Request {
+	webhookCount++
+
+	mac := hmac.New(sha256.New, []byte(app.ApiSecret))
+	mac.Write([]byte(body))
+
+	req := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(body))
+	req.Header.Set("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	req.Header.Set("X-Shopify-Topic", topic)
+	req.Header.Set("X-Shopify-Shop-Domain", "fooshop.myshopify.com")
+	req.Header.Set("X-Shopify-Webhook-Id", fmt.Sprintf("b54557e4-bdd9-4b37-8a5f-%012d", webhookCount))
+	req.Header.Set("X-Shopify-API-Version", testApiVersion)
+	return req
+}
+
+func TestWebhookHandlerDispatch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	handler := NewWebhookHandler(app)
+
+	var received Order
+	var receivedShop string
+	handler.OnOrderCreate(func(shop string, o Order) error {
+		receivedShop = shop
+		received = o
+		return nil
+	})
+
+	var delivery WebhookDelivery
+	handler.Handle("carts/create", func(d WebhookDelivery) error {
+		delivery = d
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newWebhookRequest(TopicOrdersCreate, `{"id":123,"name":"#1001"}`))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("WebhookHandler returned status %d, expected %d", w.Code, http.StatusOK)
+	}
+	if receivedShop != "fooshop.myshopify.com" || received.ID != 123 || received.Name != "#1001" {
+		t.Errorf("WebhookHandler.OnOrderCreate received %s %+v", receivedShop, received)
+	}
+
+	w = httptest.NewRecorder()
+	req := newWebhookRequest("carts/create", `{"id":"abc"}`)
+	handler.ServeHTTP(w, req)
+
+	expected := WebhookDelivery{
+		Topic:      "carts/create",
+		ShopDomain: "fooshop.myshopify.com",
+		WebhookID:  req.Header.Get("X-Shopify-Webhook-Id"),
+		APIVersion: testApiVersion,
+		Body:       []byte(`{"id":"abc"}`),
+	}
+	if w.Code != http.StatusOK || delivery.WebhookID != expected.WebhookID || delivery.APIVersion != expected.APIVersion || string(delivery.Body) != string(expected.Body) {
+		t.Errorf("WebhookHandler.Handle received %+v, expected %+v", delivery, expected)
+	}
+}
+
+func TestWebhookHandlerStatus(t *testing.T) {
+	setup()
+	defer teardown()
+
+	handler := NewWebhookHandler(app)
+	handler.OnProductUpdate(func(shop string, p Product) error {
+		if p.ID == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	unsigned := newWebhookRequest(TopicProductsUpdate, `{"id":1}`)
+	unsigned.Header.Del("X-Shopify-Hmac-Sha256")
+
+	get := newWebhookRequest(TopicProductsUpdate, `{"id":1}`)
+	get.Method = "GET"
+
+	noTopic := newWebhookRequest(TopicProductsUpdate, `{"id":1}`)
+	noTopic.Header.Del("X-Shopify-Topic")
+
+	cases := []struct {
+		description string
+		req         *http.Request
+		expected    int
+	}{
+		{"valid delivery", newWebhookRequest(TopicProductsUpdate, `{"id":1}`), http.StatusOK},
+		{"unregistered topic is acknowledged", newWebhookRequest(TopicOrdersPaid, `{"id":1}`), http.StatusOK},
+		{"unsigned request", unsigned, http.StatusUnauthorized},
+		{"wrong method", get, http.StatusMethodNotAllowed},
+		{"missing topic", noTopic, http.StatusBadRequest},
+		{"invalid payload", newWebhookRequest(TopicProductsUpdate, `{"id":"one"}`), http.StatusBadRequest},
+		{"callback error", newWebhookRequest(TopicProductsUpdate, `{}`), http.StatusInternalServerError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, c.req)
+			if w.Code != c.expected {
+				t.Errorf("WebhookHandler returned status %d, expected %d", w.Code, c.expected)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerIdempotency(t *testing.T) {
+	setup()
+	defer teardown()
+
+	handler := NewWebhookHandler(app)
+
+	calls := 0
+	fail := false
+	handler.OnOrderPaid(func(shop string, o Order) error {
+		calls++
+		if fail {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	req := newWebhookRequest(TopicOrdersPaid, `{"id":1}`)
+	webhookID := req.Header.Get("X-Shopify-Webhook-Id")
+	redeliver := func() *http.Request {
+		r := newWebhookRequest(TopicOrdersPaid, `{"id":1}`)
+		r.Header.Set("X-Shopify-Webhook-Id", webhookID)
+		return r
+	}
+
+	for _, r := range []*http.Request{req, redeliver(), redeliver()} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("WebhookHandler returned status %d, expected %d", w.Code, http.StatusOK)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("WebhookHandler invoked the callback %d times for one delivery, expected 1", calls)
+	}
+
+	// a failed delivery is processed again when redelivered
+	calls = 0
+	fail = true
+	req = newWebhookRequest(TopicOrdersPaid, `{"id":2}`)
+	webhookID = req.Header.Get("X-Shopify-Webhook-Id")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	fail = false
+	handler.ServeHTTP(httptest.NewRecorder(), redeliver())
+	if calls != 2 {
+		t.Errorf("WebhookHandler invoked the callback %d times for a failed delivery, expected 2", calls)
+	}
+
+	// without a store every delivery is processed
+	calls = 0
+	handler.SetIdempotencyStore(nil, 0)
+	handler.ServeHTTP(httptest.NewRecorder(), redeliver())
+	handler.ServeHTTP(httptest.NewRecorder(), redeliver())
+	if calls != 2 {
+		t.Errorf("WebhookHandler invoked the callback %d times without a store, expected 2", calls)
+	}
+}
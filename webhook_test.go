@@ -1,7 +1,9 @@
 package shopify
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -161,3 +163,110 @@ func TestWebhookDelete(t *testing.T) {
 		t.Errorf("Webhook.Delete returned error: %v", err)
 	}
 }
+
+func TestWebhookListAll(t *testing.T) {
+	setup()
+	defer teardown()
+
+	expected := registerWebhookPages()
+
+	var ids []int64
+	err := client.Webhook.ListAll(ListOptions{Limit: 2}, func(webhooks []Webhook) error {
+		for _, webhook := range webhooks {
+			ids = append(ids, webhook.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Webhook.ListAll returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("Webhook.ListAll returned ids %v, expected %v", ids, expected)
+	}
+}
+
+func TestWebhookSync(t *testing.T) {
+	setup()
+	defer teardown()
+
+	basePath := fmt.Sprintf("https://fooshop.myshopify.com/%s/webhooks", client.pathPrefix)
+	httpmock.RegisterResponder("GET", basePath+".json",
+		httpmock.NewStringResponder(200, `{"webhooks":[
+			{"id":1,"topic":"orders/create","address":"https://example.com/orders","format":"json","fields":["id","name"]},
+			{"id":2,"topic":"products/update","address":"https://old.example.com/products","format":"json"},
+			{"id":3,"topic":"customers/create","address":"https://example.com/customers","format":"json"}
+		]}`))
+	sent := map[string]Webhook{}
+	recordSent := func(status int, body string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			resource := WebhookResource{}
+			if err := json.NewDecoder(req.Body).Decode(&resource); err != nil {
+				return nil, err
+			}
+			sent[req.Method] = *resource.Webhook
+			return httpmock.NewStringResponse(status, body), nil
+		}
+	}
+	httpmock.RegisterResponder("PUT", basePath+"/2.json",
+		recordSent(200, `{"webhook":{"id":2,"topic":"products/update","address":"https://example.com/products","format":"json"}}`))
+	httpmock.RegisterResponder("POST", basePath+".json",
+		recordSent(201, `{"webhook":{"id":4,"topic":"app/uninstalled","address":"https://example.com/uninstalled","format":"json"}}`))
+	httpmock.RegisterResponder("DELETE", basePath+"/3.json",
+		httpmock.NewStringResponder(200, `{}`))
+
+	desired := []Webhook{
+		{Topic: "orders/create", Address: "https://example.com/orders", Fields: []string{"name", "id"}},
+		{Topic: "products/update", Address: "https://example.com/products"},
+		{Topic: "app/uninstalled", Address: "https://example.com/uninstalled"},
+	}
+
+	ids := func(webhooks []Webhook) []int64 {
+		result := []int64{}
+		for _, webhook := range webhooks {
+			result = append(result, webhook.ID)
+		}
+		return result
+	}
+
+	// dry run reports the diff without changing anything
+	report, err := client.Webhook.Sync(desired, true)
+	if err != nil {
+		t.Fatalf("Webhook.Sync returned error: %v", err)
+	}
+
+	info := httpmock.GetCallCountInfo()
+	if info["PUT "+basePath+"/2.json"] != 0 || info["POST "+basePath+".json"] != 0 || info["DELETE "+basePath+"/3.json"] != 0 {
+		t.Errorf("Webhook.Sync changed webhooks in a dry run: %v", info)
+	}
+
+	if !report.DryRun ||
+		!reflect.DeepEqual(ids(report.Unchanged), []int64{1}) ||
+		!reflect.DeepEqual(ids(report.Updated), []int64{2}) ||
+		!reflect.DeepEqual(ids(report.Deleted), []int64{3}) ||
+		len(report.Created) != 1 || report.Created[0].Topic != "app/uninstalled" ||
+		report.Created[0].Format != "json" {
+		t.Errorf("Webhook.Sync dry run returned %+v", report)
+	}
+
+	report, err = client.Webhook.Sync(desired, false)
+	if err != nil {
+		t.Fatalf("Webhook.Sync returned error: %v", err)
+	}
+
+	info = httpmock.GetCallCountInfo()
+	if info["PUT "+basePath+"/2.json"] != 1 || info["POST "+basePath+".json"] != 1 || info["DELETE "+basePath+"/3.json"] != 1 {
+		t.Errorf("Webhook.Sync made unexpected calls: %v", info)
+	}
+
+	if report.DryRun ||
+		!reflect.DeepEqual(ids(report.Created), []int64{4}) ||
+		report.Updated[0].Address != "https://example.com/products" {
+		t.Errorf("Webhook.Sync returned %+v", report)
+	}
+
+	// an empty format is sent as json, the format Shopify defaults to
+	if sent["POST"].Format != "json" || sent["PUT"].Format != "json" {
+		t.Errorf("Webhook.Sync sent formats %q and %q, expected json", sent["POST"].Format, sent["PUT"].Format)
+	}
+}